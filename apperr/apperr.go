@@ -0,0 +1,121 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+
+	"rbi_backend/store"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Code identifies the kind of failure so clients can react without parsing messages
+type Code string
+
+const (
+	CodeValidation          Code = "VALIDATION_FAILED"
+	CodeUnauthorized        Code = "UNAUTHORIZED"
+	CodeForbidden           Code = "FORBIDDEN"
+	CodeNotFound            Code = "NOT_FOUND"
+	CodeMethodNotAllowed    Code = "METHOD_NOT_ALLOWED"
+	CodeRequest             Code = "REQUEST_ERROR"
+	CodeTimeout             Code = "QUERY_TIMEOUT"
+	CodeCanceled            Code = "REQUEST_CANCELED"
+	CodeDatabaseUnavailable Code = "DATABASE_UNAVAILABLE"
+	CodeSchemaMissing       Code = "DATABASE_OBJECT_MISSING"
+	CodeDatabase            Code = "DATABASE_ERROR"
+	CodeInternal            Code = "INTERNAL_ERROR"
+)
+
+// FieldError describes a single invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is the typed error returned by handlers and rendered by Handler
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details any
+	Err     error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error with the given status, code and client-facing message
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Validation returns a 400 error listing the invalid fields
+func Validation(message string, fields []FieldError) *Error {
+	return &Error{Status: fiber.StatusBadRequest, Code: CodeValidation, Message: message, Details: fields}
+}
+
+// Wrap classifies err, typically from GORM or pgx, and attaches the client-facing
+// message; the cause is kept for logging but never sent to the client
+func Wrap(err error, message string) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	status, code := classify(err)
+	return &Error{Status: status, Code: code, Message: message, Err: err}
+}
+
+// classify maps database and context errors onto an HTTP status and error code
+func classify(err error) (int, Code) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fiber.StatusGatewayTimeout, CodeTimeout
+	case errors.Is(err, context.Canceled):
+		return fiber.StatusRequestTimeout, CodeCanceled
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound, CodeNotFound
+	case errors.Is(err, store.ErrUnsupportedFilter), errors.Is(err, store.ErrInvalidCursor):
+		return fiber.StatusBadRequest, CodeValidation
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "57014": // query_canceled, raised by statement_timeout
+			return fiber.StatusGatewayTimeout, CodeTimeout
+		case pgErr.Code == "42883", pgErr.Code == "42P01": // undefined_function, undefined_table
+			return fiber.StatusNotImplemented, CodeSchemaMissing
+		case pgErr.Code[:2] == "08", pgErr.Code == "53300", pgErr.Code == "57P01", pgErr.Code == "57P03":
+			return fiber.StatusServiceUnavailable, CodeDatabaseUnavailable
+		}
+		return fiber.StatusInternalServerError, CodeDatabase
+	}
+
+	var connErr *pgconn.ConnectError
+	var opErr *net.OpError
+	switch {
+	case pgconn.Timeout(err):
+		return fiber.StatusGatewayTimeout, CodeTimeout
+	case errors.As(err, &connErr), errors.As(err, &opErr), errors.Is(err, syscall.ECONNREFUSED):
+		return fiber.StatusServiceUnavailable, CodeDatabaseUnavailable
+	}
+
+	return fiber.StatusInternalServerError, CodeDatabase
+}
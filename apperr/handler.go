@@ -0,0 +1,69 @@
+package apperr
+
+import (
+	"errors"
+	"log/slog"
+
+	"rbi_backend/logging"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Response is the JSON body sent for every failed request
+type Response struct {
+	Code      Code   `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// RequestID returns the ID assigned to the request by the requestid middleware
+func RequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals("requestid").(string)
+	return id
+}
+
+// Handler is the Fiber ErrorHandler rendering every error as a Response
+func Handler(c *fiber.Ctx, err error) error {
+	appErr := toError(err)
+	requestID := RequestID(c)
+
+	if appErr.Status >= fiber.StatusInternalServerError {
+		logging.FromContext(c.UserContext()).Error("request failed",
+			slog.String("code", string(appErr.Code)),
+			slog.String("error", appErr.Error()),
+		)
+	}
+
+	return c.Status(appErr.Status).JSON(Response{
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		Details:   appErr.Details,
+		RequestID: requestID,
+	})
+}
+
+// toError converts any error returned by a handler into an *Error
+func toError(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		switch fiberErr.Code {
+		case fiber.StatusNotFound:
+			return New(fiberErr.Code, CodeNotFound, fiberErr.Message)
+		case fiber.StatusMethodNotAllowed:
+			return New(fiberErr.Code, CodeMethodNotAllowed, fiberErr.Message)
+		case fiber.StatusBadRequest:
+			return New(fiberErr.Code, CodeValidation, fiberErr.Message)
+		}
+		if fiberErr.Code < fiber.StatusInternalServerError {
+			return New(fiberErr.Code, CodeRequest, fiberErr.Message)
+		}
+	}
+
+	return &Error{Status: fiber.StatusInternalServerError, Code: CodeInternal, Message: "Internal server error", Err: err}
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"rbi_backend/apperr"
+	"rbi_backend/config"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// principalKey is the c.Locals key holding the authenticated Principal
+const principalKey = "principal"
+
+// Principal is the authenticated caller of a request, identified by the
+// token's subject; what they may see is resolved by the rbac package
+type Principal struct {
+	Subject string
+}
+
+// Authenticator validates bearer tokens against a locally stored key
+type Authenticator struct {
+	key    any
+	parser *jwt.Parser
+}
+
+// NewAuthenticator loads the verification key named by cfg: the shared secret
+// for HS256 or a PEM encoded public key for RS256
+func NewAuthenticator(cfg config.AuthConfig) (*Authenticator, error) {
+	data, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read key file: %w", err)
+	}
+
+	var key any
+	switch cfg.Algorithm {
+	case "HS256":
+		secret := bytes.TrimSpace(data)
+		if len(secret) < 32 {
+			return nil, errors.New("auth: HS256 secret must be at least 32 bytes")
+		}
+		key = secret
+	case "RS256":
+		key, err = jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("auth: parse RS256 public key: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("auth: unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{cfg.Algorithm}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &Authenticator{key: key, parser: jwt.NewParser(opts...)}, nil
+}
+
+// Authenticate verifies a raw token and returns the principal it identifies
+func (a *Authenticator) Authenticate(raw string) (Principal, error) {
+	var claims jwt.RegisteredClaims
+	if _, err := a.parser.ParseWithClaims(raw, &claims, func(*jwt.Token) (any, error) { return a.key, nil }); err != nil {
+		return Principal{}, err
+	}
+
+	if claims.Subject == "" {
+		return Principal{}, errors.New("token has no subject")
+	}
+
+	return Principal{Subject: claims.Subject}, nil
+}
+
+// Middleware rejects requests without a valid bearer token and stores the
+// authenticated Principal for the handlers
+func (a *Authenticator) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		raw, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(raw) == "" {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="rbi_backend"`)
+			return apperr.New(fiber.StatusUnauthorized, apperr.CodeUnauthorized, "Missing bearer token")
+		}
+
+		principal, err := a.Authenticate(strings.TrimSpace(raw))
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="rbi_backend", error="invalid_token"`)
+			return &apperr.Error{Status: fiber.StatusUnauthorized, Code: apperr.CodeUnauthorized, Message: "Invalid bearer token", Err: err}
+		}
+
+		c.Locals(principalKey, principal)
+		return c.Next()
+	}
+}
+
+// FromCtx returns the principal stored by Middleware, if any
+func FromCtx(c *fiber.Ctx) (Principal, bool) {
+	p, ok := c.Locals(principalKey).(Principal)
+	return p, ok
+}
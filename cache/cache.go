@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Entry is a cached dashboard response
+type Entry struct {
+	ContentType string            `json:"content_type"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body"`
+	ETag        string            `json:"etag"`
+}
+
+// Store is a cache backend. Keys are built by Key so entries can be
+// invalidated by report and subject without knowing the full key.
+type Store interface {
+	// Get returns the live entry for key, if any
+	Get(ctx context.Context, key string) (Entry, bool, error)
+	// Set stores an entry that expires after ttl
+	Set(ctx context.Context, key string, e Entry, ttl time.Duration) error
+	// Invalidate removes the entries of a report and subject, where an empty
+	// value matches any, and returns how many were removed. A subject also
+	// matches the scope-wide entries, whose rows may include it.
+	Invalidate(ctx context.Context, report, subject string) (int, error)
+}
+
+// Key builds the key of a response from its report name, the officer, unit,
+// center or branch it covers and a digest of the remaining parameters
+func Key(report, subject, digest string) string {
+	return report + ":" + escape(subject) + ":" + digest
+}
+
+// scopeWide is the key part of the responses covering the caller's whole scope
+// rather than one named subject
+const scopeWide = "-"
+
+// escape makes a subject safe to embed in a key: the result contains no ':'
+// and no glob characters, and the empty subject is scopeWide
+func escape(subject string) string {
+	if subject == "" {
+		return scopeWide
+	}
+	return url.QueryEscape(subject)
+}
+
+// matches reports whether key belongs to the report and subject, where an
+// empty value matches any and a subject also matches the scope-wide entries
+func matches(key, report, subject string) bool {
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) != 3 {
+		return false
+	}
+	return (report == "" || parts[0] == report) && (subject == "" || parts[1] == escape(subject) || parts[1] == scopeWide)
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process Store holding at most a fixed number of entries,
+// evicting the least recently used first
+type LRU struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+// lruItem is the value held in each list element
+type lruItem struct {
+	key       string
+	entry     Entry
+	expiresAt time.Time
+}
+
+// NewLRU returns an empty LRU holding up to max entries
+func NewLRU(max int) *LRU {
+	return &LRU{max: max, order: list.New(), entries: map[string]*list.Element{}, now: time.Now}
+}
+
+// Get returns the entry for key unless it has expired
+func (l *LRU) Get(ctx context.Context, key string) (Entry, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.entries[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	item := el.Value.(*lruItem)
+	if !l.now().Before(item.expiresAt) {
+		l.remove(el)
+		return Entry{}, false, nil
+	}
+	l.order.MoveToFront(el)
+	return item.entry, true, nil
+}
+
+// Set stores the entry, evicting the least recently used one when full
+func (l *LRU) Set(ctx context.Context, key string, e Entry, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt := l.now().Add(ttl)
+	if el, ok := l.entries[key]; ok {
+		el.Value = &lruItem{key: key, entry: e, expiresAt: expiresAt}
+		l.order.MoveToFront(el)
+		return nil
+	}
+
+	l.entries[key] = l.order.PushFront(&lruItem{key: key, entry: e, expiresAt: expiresAt})
+	for l.order.Len() > l.max {
+		l.remove(l.order.Back())
+	}
+	return nil
+}
+
+// Invalidate removes every entry of the report and subject
+func (l *LRU) Invalidate(ctx context.Context, report, subject string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := 0
+	for key, el := range l.entries {
+		if matches(key, report, subject) {
+			l.remove(el)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Len returns the number of entries held, including expired ones not yet evicted
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// remove drops an element from the list and index; the lock must be held
+func (l *LRU) remove(el *list.Element) {
+	l.order.Remove(el)
+	delete(l.entries, el.Value.(*lruItem).key)
+}
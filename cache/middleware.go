@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rbi_backend/apperr"
+	"rbi_backend/export"
+	"rbi_backend/handlers/params"
+	"rbi_backend/logging"
+	"rbi_backend/monitoring"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// cachedHeaders are the response headers replayed with a cached body
+var cachedHeaders = []string{fiber.HeaderContentDisposition, "X-Total-Count", "X-Next-Cursor"}
+
+// filterParams are folded into the key through the normalized filter rather than as raw values
+var filterParams = map[string]bool{
+	"account_officer": true,
+	"unit_name":       true,
+	"center_name":     true,
+	"branch_name":     true,
+	"start_date":      true,
+	"end_date":        true,
+	"format":          true,
+}
+
+// Cache serves repeated dashboard requests from a Store and answers
+// If-None-Match with 304 Not Modified
+type Cache struct {
+	store      Store
+	defaultTTL time.Duration
+	ttls       map[string]time.Duration
+
+	mu      sync.Mutex
+	reports map[string]bool
+}
+
+// New returns a Cache over store; ttls overrides defaultTTL per report and a
+// zero TTL disables caching for that report
+func New(store Store, defaultTTL time.Duration, ttls map[string]time.Duration) *Cache {
+	return &Cache{store: store, defaultTTL: defaultTTL, ttls: ttls, reports: map[string]bool{}}
+}
+
+// For is middleware caching a report's successful responses. It must run
+// after the dashboard filter middleware, which authorizes the request and
+// provides the filter the key is built from.
+func (ca *Cache) For(report string) fiber.Handler {
+	ca.mu.Lock()
+	ca.reports[report] = true
+	ca.mu.Unlock()
+
+	ttl, ok := ca.ttls[report]
+	if !ok {
+		ttl = ca.defaultTTL
+	}
+	if ttl <= 0 {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		f := params.Filter(c)
+		key := Key(report, f.Value, digest(c, f))
+
+		entry, hit, err := ca.store.Get(ctx, key)
+		if err != nil {
+			// A failing cache must not take the dashboards down with it
+			logging.FromContext(ctx).Warn("Cache read failed", slog.String("report", report), slog.Any("error", err))
+		}
+		monitoring.CacheLookup(report, hit)
+		if hit {
+			return serve(c, entry, "HIT")
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		resp := c.Response()
+		// Handlers mark incomplete responses no-store, such as a partial summary
+		if resp.StatusCode() != fiber.StatusOK || resp.IsBodyStream() || strings.Contains(c.GetRespHeader(fiber.HeaderCacheControl), "no-store") {
+			return nil
+		}
+		entry = Entry{
+			ContentType: string(resp.Header.ContentType()),
+			Headers:     map[string]string{},
+			Body:        append([]byte(nil), resp.Body()...),
+		}
+		for _, h := range cachedHeaders {
+			if v := c.GetRespHeader(h); v != "" {
+				entry.Headers[h] = v
+			}
+		}
+		entry.ETag = etag(entry.Body)
+
+		if err := ca.store.Set(ctx, key, entry, ttl); err != nil {
+			logging.FromContext(ctx).Warn("Cache write failed", slog.String("report", report), slog.Any("error", err))
+		}
+		return serve(c, entry, "MISS")
+	}
+}
+
+// serve writes an entry, or 304 Not Modified when the client already has it
+func serve(c *fiber.Ctx, e Entry, status string) error {
+	c.Set("X-Cache", status)
+	c.Set(fiber.HeaderETag, e.ETag)
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	for h, v := range e.Headers {
+		c.Set(h, v)
+	}
+
+	if notModified(c.Get(fiber.HeaderIfNoneMatch), e.ETag) {
+		c.Response().ResetBody()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, e.ContentType)
+	return c.Status(fiber.StatusOK).Send(e.Body)
+}
+
+// notModified reports whether an If-None-Match header matches the entity tag
+func notModified(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// etag is a strong entity tag over the response body
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// digest identifies everything besides the subject that shapes the response:
+// the path, the normalized filter including the caller's scope, the response
+// format and any report-specific query parameters
+func digest(c *fiber.Ctx, f params.DashboardFilter) string {
+	sf := f.StoreFilter()
+	sort.Strings(sf.Officers)
+
+	var extra []string
+	c.Context().QueryArgs().VisitAll(func(k, v []byte) {
+		if !filterParams[string(k)] {
+			extra = append(extra, string(k)+"="+string(v))
+		}
+	})
+	sort.Strings(extra)
+
+	data, _ := json.Marshal(struct {
+		Path   string
+		Filter any
+		Format export.Format
+		Extra  []string
+	}{strings.ToLower(c.Path()), sf, export.FromCtx(c), extra})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:12])
+}
+
+// Invalidate handles DELETE /cache and DELETE /cache/{report}, dropping the
+// cached responses of every report or one, optionally for one account_officer,
+// unit_name, center_name or branch_name together with the scope-wide responses
+// that may include it. It is mounted for administrators only.
+func (ca *Cache) Invalidate(c *fiber.Ctx) error {
+	report := c.Params("report")
+	if report != "" {
+		ca.mu.Lock()
+		known := ca.reports[report]
+		ca.mu.Unlock()
+		if !known {
+			return apperr.New(fiber.StatusNotFound, apperr.CodeNotFound, "Unknown report "+report)
+		}
+	}
+
+	subject := ""
+	for _, level := range []params.Level{params.LevelOfficer, params.LevelUnit, params.LevelCenter, params.LevelBranch} {
+		if v := strings.TrimSpace(c.Query(string(level))); v != "" {
+			subject = v
+			break
+		}
+	}
+
+	n, err := ca.store.Invalidate(c.UserContext(), report, subject)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to invalidate cache")
+	}
+	logging.FromContext(c.UserContext()).Info("Cache invalidated", slog.String("report", report), slog.String("subject", subject), slog.Int("entries", n))
+	return c.JSON(fiber.Map{"invalidated": n})
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rbi_backend/handlers/params"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name                    string
+		report, subject, digest string
+		want                    string
+	}{
+		{"named subject", "center-summary", "Jane Doe", "abc", "center-summary:Jane+Doe:abc"},
+		{"scope-wide", "center-summary", "", "abc", "center-summary:-:abc"},
+		{"separator and glob characters escaped", "clients", "a:b*c?[d]", "abc", "clients:a%3Ab%2Ac%3F%5Bd%5D:abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Key(tt.report, tt.subject, tt.digest); got != tt.want {
+				t.Errorf("Key(%q, %q, %q) = %q, want %q", tt.report, tt.subject, tt.digest, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name            string
+		key             string
+		report, subject string
+		want            bool
+	}{
+		{"everything", Key("clients", "Jane", "d"), "", "", true},
+		{"report", Key("clients", "Jane", "d"), "clients", "", true},
+		{"other report", Key("clients", "Jane", "d"), "center-summary", "", false},
+		{"subject", Key("clients", "Jane", "d"), "", "Jane", true},
+		{"other subject", Key("clients", "John", "d"), "", "Jane", false},
+		{"scope-wide entry of a subject", Key("clients", "", "d"), "clients", "Jane", true},
+		{"scope-wide entry of another report", Key("clients", "", "d"), "center-summary", "Jane", false},
+		{"malformed key", "clients", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(tt.key, tt.report, tt.subject); got != tt.want {
+				t.Errorf("matches(%q, %q, %q) = %v, want %v", tt.key, tt.report, tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidateSubjectDropsScopeWideEntries(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(10)
+	for _, key := range []string{
+		Key("clients", "Jane", "d"),
+		Key("clients", "John", "d"),
+		Key("clients", "", "d"),
+		Key("center-summary", "Jane", "d"),
+	} {
+		lru.Set(ctx, key, Entry{}, time.Minute)
+	}
+
+	n, err := lru.Invalidate(ctx, "clients", "Jane")
+	if err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Invalidate removed %d entries, want 2", n)
+	}
+	for key, want := range map[string]bool{
+		Key("clients", "Jane", "d"):        false,
+		Key("clients", "", "d"):            false,
+		Key("clients", "John", "d"):        true,
+		Key("center-summary", "Jane", "d"): true,
+	} {
+		if _, ok, _ := lru.Get(ctx, key); ok != want {
+			t.Errorf("%s cached = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+// newApp serves the officer dashboard's report through the cache, counting
+// the calls reaching handler
+func newApp(ca *Cache, handler fiber.Handler) (*fiber.App, *int) {
+	calls := 0
+	app := fiber.New()
+	app.Get("/report", params.RequireDashboardFilter(params.LevelOfficer, params.Options{}), ca.For("report"), func(c *fiber.Ctx) error {
+		calls++
+		return handler(c)
+	})
+	return app, &calls
+}
+
+const reportURL = "/report?account_officer=Jane&start_date=2024-01-01&end_date=2024-01-31"
+
+func TestCachedResponseAnswersIfNoneMatch(t *testing.T) {
+	app, calls := newApp(New(NewLRU(10), time.Minute, nil), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"count": 1})
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, reportURL, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := resp.Header.Get(fiber.HeaderETag)
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get("X-Cache") != "MISS" || tag == "" {
+		t.Fatalf("first response = %d, X-Cache %q, ETag %q", resp.StatusCode, resp.Header.Get("X-Cache"), tag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"matching tag", tag, fiber.StatusNotModified},
+		{"weak matching tag in a list", `"other", W/` + tag, fiber.StatusNotModified},
+		{"stale tag", `"other"`, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, reportURL, nil)
+			req.Header.Set(fiber.HeaderIfNoneMatch, tt.ifNoneMatch)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.want || resp.Header.Get("X-Cache") != "HIT" {
+				t.Errorf("response = %d, X-Cache %q, want %d HIT", resp.StatusCode, resp.Header.Get("X-Cache"), tt.want)
+			}
+			if tt.want == fiber.StatusNotModified && len(body) != 0 {
+				t.Errorf("304 response has body %q", body)
+			}
+		})
+	}
+	if *calls != 1 {
+		t.Errorf("handler ran %d times, want 1", *calls)
+	}
+}
+
+func TestUncacheableResponsesAreNotStored(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler fiber.Handler
+	}{
+		{"error status", func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed"})
+		}},
+		{"stream", func(c *fiber.Ctx) error {
+			return c.SendStream(bytes.NewReader([]byte("a,b\n")))
+		}},
+		{"no-store", func(c *fiber.Ctx) error {
+			c.Set(fiber.HeaderCacheControl, "no-store")
+			return c.JSON(fiber.Map{"partial": true})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lru := NewLRU(10)
+			app, calls := newApp(New(lru, time.Minute, nil), tt.handler)
+			for i := 0; i < 2; i++ {
+				resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, reportURL, nil))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.Header.Get("X-Cache") != "" {
+					t.Errorf("response %d has X-Cache %q", i+1, resp.Header.Get("X-Cache"))
+				}
+			}
+			if lru.Len() != 0 {
+				t.Errorf("cache holds %d entries, want none", lru.Len())
+			}
+			if *calls != 2 {
+				t.Errorf("handler ran %d times, want 2", *calls)
+			}
+		})
+	}
+}
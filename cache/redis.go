@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"rbi_backend/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the cache in a shared Redis database
+const keyPrefix = "rbi:cache:"
+
+// Redis is a Store on any server speaking the Redis protocol, shared by every
+// backend instance pointed at it
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis connects to the configured server and checks it answers
+func NewRedis(ctx context.Context, cfg config.RedisConfig) (*Redis, error) {
+	client := redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &Redis{client: client}, nil
+}
+
+// Get returns the entry for key; Redis expires entries itself
+func (r *Redis) Get(ctx context.Context, key string) (Entry, bool, error) {
+	data, err := r.client.Get(ctx, keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Entry{}, false, err
+	}
+	return e, true, nil
+}
+
+// Set stores the entry with the given expiry
+func (r *Redis) Set(ctx context.Context, key string, e Entry, ttl time.Duration) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, keyPrefix+key, data, ttl).Err()
+}
+
+// Invalidate scans for the matching keys and deletes them in batches
+func (r *Redis) Invalidate(ctx context.Context, report, subject string) (int, error) {
+	patterns := []string{keyPrefix + "*"}
+	if report != "" || subject != "" {
+		reportPart := "*"
+		if report != "" {
+			reportPart = report
+		}
+		patterns = []string{keyPrefix + reportPart + ":*:*"}
+		if subject != "" {
+			patterns = []string{
+				keyPrefix + reportPart + ":" + escape(subject) + ":*",
+				keyPrefix + reportPart + ":" + scopeWide + ":*",
+			}
+		}
+	}
+
+	n := 0
+	for _, pattern := range patterns {
+		deleted, err := r.invalidate(ctx, pattern)
+		n += deleted
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// invalidate deletes the keys matching pattern
+func (r *Redis) invalidate(ctx context.Context, pattern string) (int, error) {
+	n := 0
+	iter := r.client.Scan(ctx, 0, pattern, 500).Iterator()
+	batch := make([]string, 0, 500)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		deleted, err := r.client.Del(ctx, batch...).Result()
+		n += int(deleted)
+		batch = batch[:0]
+		return err
+	}
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return n, err
+	}
+	return n, flush()
+}
+
+// Ping checks the server answers
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close closes the connection pool
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
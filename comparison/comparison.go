@@ -0,0 +1,219 @@
+// Package comparison sets a dashboard report against the same report over an
+// earlier date range, such as the previous month or the same month a year
+// before, and reports how each of its numeric values changed.
+package comparison
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Mode chooses the date range a report is compared against
+type Mode string
+
+const (
+	// PreviousPeriod compares against the range of the same length ending the
+	// day before the requested one; whole calendar months are compared against
+	// the same number of whole months
+	PreviousPeriod Mode = "previous_period"
+	// PreviousYear compares against the same range one year earlier
+	PreviousYear Mode = "previous_year"
+)
+
+// Modes lists every supported mode
+var Modes = []Mode{PreviousPeriod, PreviousYear}
+
+// ParseMode returns the mode named by s, ignoring case
+func ParseMode(s string) (Mode, bool) {
+	m := Mode(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Modes {
+		if m == known {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// Range returns the range [start, end] is compared against
+func (m Mode) Range(start, end time.Time) (time.Time, time.Time) {
+	if m == PreviousYear {
+		from := addMonths(start, -12)
+		to := addMonths(end, -12)
+		if isMonthEnd(end) {
+			to = monthEnd(to)
+		}
+		return from, to
+	}
+
+	if start.Day() == 1 && isMonthEnd(end) {
+		months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+		return start.AddDate(0, -months, 0), start.AddDate(0, 0, -1)
+	}
+	days := int(end.Sub(start).Hours()/24) + 1
+	return start.AddDate(0, 0, -days), start.AddDate(0, 0, -1)
+}
+
+// addMonths moves t by n months, keeping its day unless the target month is
+// shorter, so 2024-02-29 a year earlier is 2023-02-28 rather than March 1st
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, n, 0)
+	return first.AddDate(0, 0, min(t.Day(), monthEnd(first).Day())-1)
+}
+
+// monthEnd returns the last day of t's month
+func monthEnd(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location())
+}
+
+// isMonthEnd reports whether t is the last day of its month
+func isMonthEnd(t time.Time) bool {
+	return t.Day() == monthEnd(t).Day()
+}
+
+// Record is one report row split into the text fields identifying it, such as
+// particulars, and its numeric values, such as count and amount
+type Record struct {
+	Key    map[string]string
+	Values map[string]float64
+}
+
+// Records splits struct rows by their JSON field names: string fields make up
+// the key and integer and float fields the values
+func Records[T any](rows []T) []Record {
+	records := make([]Record, len(rows))
+	for i, row := range rows {
+		v := reflect.ValueOf(row)
+		r := Record{Key: map[string]string{}, Values: map[string]float64{}}
+		for j := 0; j < v.NumField(); j++ {
+			field := v.Type().Field(j)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			switch f := v.Field(j); f.Kind() {
+			case reflect.String:
+				r.Key[name] = f.String()
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				r.Values[name] = float64(f.Int())
+			case reflect.Float32, reflect.Float64:
+				r.Values[name] = f.Float()
+			}
+		}
+		records[i] = r
+	}
+	return records
+}
+
+// Change is one value over the requested and the comparison range
+type Change struct {
+	Current    float64 `json:"current"`
+	Comparison float64 `json:"comparison"`
+	// Change is Current minus Comparison
+	Change float64 `json:"change"`
+	// PercentChange is Change as a percentage of Comparison, rounded to two
+	// decimals; null when Comparison is zero
+	PercentChange *float64 `json:"percent_change"`
+}
+
+// NewChange computes the change from comparison to current
+func NewChange(current, comparison float64) Change {
+	// Round away the float noise of the subtraction, e.g. 234.44000000000005
+	c := Change{Current: current, Comparison: comparison, Change: math.Round((current-comparison)*1e9) / 1e9}
+	if comparison != 0 {
+		pct := math.Round(c.Change/math.Abs(comparison)*10000) / 100
+		c.PercentChange = &pct
+	}
+	return c
+}
+
+// Row is one compared report row
+type Row struct {
+	// Key holds the text fields identifying the row, omitted for reports with a single row and no text fields
+	Key    map[string]string `json:"key,omitempty"`
+	Values map[string]Change `json:"values"`
+}
+
+// Period is an inclusive date range
+type Period struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// Report is the body of a compared dashboard report
+type Report struct {
+	Compare          Mode   `json:"compare"`
+	Period           Period `json:"period"`
+	ComparisonPeriod Period `json:"comparison_period"`
+	Rows             []Row  `json:"rows"`
+}
+
+// Compare matches current and comparison records by key. Rows keep the order
+// of current, followed by those found only in comparison; a row or value
+// missing from either side counts as zero there.
+func Compare(current, comparison []Record) []Row {
+	previous := make(map[string]Record, len(comparison))
+	for _, r := range comparison {
+		previous[keyOf(r.Key)] = r
+	}
+
+	rows := make([]Row, 0, len(current))
+	seen := make(map[string]bool, len(current))
+	for _, r := range current {
+		k := keyOf(r.Key)
+		seen[k] = true
+		rows = append(rows, compareRecord(r, previous[k]))
+	}
+	for _, r := range comparison {
+		if k := keyOf(r.Key); !seen[k] {
+			seen[k] = true
+			rows = append(rows, compareRecord(Record{Key: r.Key}, r))
+		}
+	}
+	return rows
+}
+
+// compareRecord compares the values of two records with the same key
+func compareRecord(current, comparison Record) Row {
+	key := current.Key
+	if key == nil {
+		key = comparison.Key
+	}
+	row := Row{Values: map[string]Change{}}
+	if len(key) > 0 {
+		row.Key = key
+	}
+	for name, v := range current.Values {
+		row.Values[name] = NewChange(v, comparison.Values[name])
+	}
+	for name, v := range comparison.Values {
+		if _, ok := current.Values[name]; !ok {
+			row.Values[name] = NewChange(0, v)
+		}
+	}
+	return row
+}
+
+// keyOf flattens a record key into a comparable string
+func keyOf(key map[string]string) string {
+	names := make([]string, 0, len(key))
+	for name := range key {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(0)
+		b.WriteString(key[name])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
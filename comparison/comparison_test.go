@@ -0,0 +1,131 @@
+package comparison
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       Mode
+		start, end string
+		want       [2]string
+	}{
+		{"whole month against the previous month", PreviousPeriod, "2024-03-01", "2024-03-31", [2]string{"2024-02-01", "2024-02-29"}},
+		{"whole February against January", PreviousPeriod, "2024-02-01", "2024-02-29", [2]string{"2024-01-01", "2024-01-31"}},
+		{"whole quarter against the previous quarter", PreviousPeriod, "2024-01-01", "2024-03-31", [2]string{"2023-10-01", "2023-12-31"}},
+		{"partial month by day count", PreviousPeriod, "2024-03-05", "2024-03-18", [2]string{"2024-02-20", "2024-03-04"}},
+		{"month ending early by day count", PreviousPeriod, "2024-03-01", "2024-03-30", [2]string{"2024-01-31", "2024-02-29"}},
+		{"single day", PreviousPeriod, "2024-03-01", "2024-03-01", [2]string{"2024-02-29", "2024-02-29"}},
+		{"same month a year earlier", PreviousYear, "2024-03-01", "2024-03-31", [2]string{"2023-03-01", "2023-03-31"}},
+		{"leap day clamped to the end of February", PreviousYear, "2024-02-29", "2024-02-29", [2]string{"2023-02-28", "2023-02-28"}},
+		{"leap February against the shorter one", PreviousYear, "2024-02-01", "2024-02-29", [2]string{"2023-02-01", "2023-02-28"}},
+		{"month end extended to the longer February", PreviousYear, "2025-02-01", "2025-02-28", [2]string{"2024-02-01", "2024-02-29"}},
+		{"partial range", PreviousYear, "2024-03-05", "2024-03-18", [2]string{"2023-03-05", "2023-03-18"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := tt.mode.Range(date(tt.start), date(tt.end))
+			got := [2]string{from.Format(time.DateOnly), to.Format(time.DateOnly)}
+			if got != tt.want {
+				t.Errorf("%s.Range(%s, %s) = %v, want %v", tt.mode, tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewChange(t *testing.T) {
+	pct := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name                string
+		current, comparison float64
+		want                Change
+	}{
+		{"growth", 12, 8, Change{Current: 12, Comparison: 8, Change: 4, PercentChange: pct(50)}},
+		{"decline", 6, 8, Change{Current: 6, Comparison: 8, Change: -2, PercentChange: pct(-25)}},
+		{"zero base", 5, 0, Change{Current: 5, Comparison: 0, Change: 5}},
+		{"both zero", 0, 0, Change{}},
+		{"negative base", -50, -100, Change{Current: -50, Comparison: -100, Change: 50, PercentChange: pct(50)}},
+		{"float noise and rounding", 1234.56, 1000.12, Change{Current: 1234.56, Comparison: 1000.12, Change: 234.44, PercentChange: pct(23.44)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewChange(tt.current, tt.comparison); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewChange(%g, %g) = %+v, want %+v", tt.current, tt.comparison, got, tt.want)
+			}
+		})
+	}
+}
+
+type result struct {
+	Particulars string  `json:"particulars"`
+	Count       int     `json:"count"`
+	Amount      float64 `json:"amount"`
+	Ignored     string  `json:"-"`
+}
+
+func TestCompareMatchesRowsByKey(t *testing.T) {
+	current := Records([]result{
+		{Particulars: "Active", Count: 10, Amount: 100},
+		{Particulars: "New", Count: 3, Amount: 30},
+	})
+	previous := Records([]result{
+		{Particulars: "Resigned", Count: 2, Amount: 20},
+		{Particulars: "Active", Count: 8, Amount: 0},
+	})
+
+	pct := func(v float64) *float64 { return &v }
+	want := []Row{
+		{Key: map[string]string{"particulars": "Active"}, Values: map[string]Change{
+			"count":  {Current: 10, Comparison: 8, Change: 2, PercentChange: pct(25)},
+			"amount": {Current: 100, Comparison: 0, Change: 100},
+		}},
+		{Key: map[string]string{"particulars": "New"}, Values: map[string]Change{
+			"count":  {Current: 3, Change: 3},
+			"amount": {Current: 30, Change: 30},
+		}},
+		{Key: map[string]string{"particulars": "Resigned"}, Values: map[string]Change{
+			"count":  {Comparison: 2, Change: -2, PercentChange: pct(-100)},
+			"amount": {Comparison: 20, Change: -20, PercentChange: pct(-100)},
+		}},
+	}
+	if got := Compare(current, previous); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompareSingleRowWithoutKey(t *testing.T) {
+	type ages struct {
+		Age18To29 int `json:"age_18_29"`
+		Total     int `json:"total"`
+	}
+	got := Compare(Records([]ages{{Age18To29: 4, Total: 4}}), Records([]ages{{Total: 2}}))
+
+	pct := func(v float64) *float64 { return &v }
+	want := []Row{{Values: map[string]Change{
+		"age_18_29": {Current: 4, Change: 4},
+		"total":     {Current: 4, Comparison: 2, Change: 2, PercentChange: pct(100)},
+	}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if m, ok := ParseMode(" Previous_Year "); !ok || m != PreviousYear {
+		t.Errorf("ParseMode(Previous_Year) = %q, %v", m, ok)
+	}
+	if _, ok := ParseMode("last_week"); ok {
+		t.Error("ParseMode accepted last_week")
+	}
+}
@@ -0,0 +1,750 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"rbi_backend/timeseries"
+
+	"github.com/BurntSushi/toml"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds all runtime settings for the backend
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Dashboard DashboardConfig `yaml:"dashboard" toml:"dashboard"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	Report    ReportConfig    `yaml:"report" toml:"report"`
+	Schedule  ScheduleConfig  `yaml:"schedule" toml:"schedule"`
+	Cache     CacheConfig     `yaml:"cache" toml:"cache"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	API       APIConfig       `yaml:"api" toml:"api"`
+}
+
+// ServerConfig holds the HTTP server settings
+type ServerConfig struct {
+	Addr        string   `yaml:"addr" toml:"addr"`
+	CORSOrigins []string `yaml:"cors_origins" toml:"cors_origins"`
+	// ShutdownTimeout is how long in-flight requests and report jobs get to
+	// finish after SIGINT or SIGTERM before they are cut off
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+// DatabaseConfig holds the Postgres connection settings
+type DatabaseConfig struct {
+	Driver       string `yaml:"driver" toml:"driver"`
+	Host         string `yaml:"host" toml:"host"`
+	Port         int    `yaml:"port" toml:"port"`
+	User         string `yaml:"user" toml:"user"`
+	Password     string `yaml:"password" toml:"password"`
+	Name         string `yaml:"name" toml:"name"`
+	SSLMode      string `yaml:"sslmode" toml:"sslmode"`
+	MaxOpenConns int    `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns int    `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	// ConnMaxLifetime and ConnMaxIdleTime recycle pooled connections; 0 keeps them forever
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	// ConnectRetries is how many times a failed startup connection is retried,
+	// waiting ConnectBackoff first and doubling the wait up to MaxConnectBackoff
+	ConnectRetries    int           `yaml:"connect_retries" toml:"connect_retries"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff" toml:"connect_backoff"`
+	MaxConnectBackoff time.Duration `yaml:"max_connect_backoff" toml:"max_connect_backoff"`
+	// HealthCheckInterval is how often the pool is pinged; 0 disables the checks
+	HealthCheckInterval time.Duration `yaml:"health_check_interval" toml:"health_check_interval"`
+	// QueryTimeout bounds the queries of one request; 0 leaves them unbounded
+	QueryTimeout time.Duration `yaml:"query_timeout" toml:"query_timeout"`
+	// QueryTimeouts overrides QueryTimeout per report or metric name (e.g. capital-build-up, clients_by_unit)
+	QueryTimeouts map[string]time.Duration `yaml:"query_timeouts" toml:"query_timeouts"`
+}
+
+// LogConfig holds the logging settings
+type LogConfig struct {
+	Level              string        `yaml:"level" toml:"level"`
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" toml:"slow_query_threshold"`
+}
+
+// DashboardConfig holds the limits applied to dashboard queries
+type DashboardConfig struct {
+	MaxRangeDays int `yaml:"max_range_days" toml:"max_range_days"`
+	// MetricsFile declares extra metrics served by GET /metrics/{name}
+	MetricsFile string `yaml:"metrics_file" toml:"metrics_file"`
+	// SummaryTimeout is the deadline shared by every section of /api/v1/ao-dashboard/summary
+	SummaryTimeout time.Duration `yaml:"summary_timeout" toml:"summary_timeout"`
+	// WeekStart is the first day of the weeks of the trend reports when the
+	// request names none; monday gives ISO 8601 weeks
+	WeekStart string `yaml:"week_start" toml:"week_start"`
+	// MemberStatuses lists the member_status values the dashboard accepts; empty
+	// accepts any status
+	MemberStatuses []string `yaml:"member_statuses" toml:"member_statuses"`
+}
+
+// Weekday returns WeekStart as a time.Weekday; it must have passed Validate
+func (d DashboardConfig) Weekday() time.Weekday {
+	day, _ := timeseries.ParseWeekday(d.WeekStart)
+	return day
+}
+
+// AuthConfig holds the JWT bearer authentication settings
+type AuthConfig struct {
+	Enabled   bool          `yaml:"enabled" toml:"enabled"`
+	Algorithm string        `yaml:"algorithm" toml:"algorithm"`
+	KeyFile   string        `yaml:"key_file" toml:"key_file"`
+	Issuer    string        `yaml:"issuer" toml:"issuer"`
+	Audience  string        `yaml:"audience" toml:"audience"`
+	Leeway    time.Duration `yaml:"leeway" toml:"leeway"`
+	// HierarchyFile is the YAML role hierarchy; without it every user is an
+	// officer and branch dashboards are unavailable
+	HierarchyFile string `yaml:"hierarchy_file" toml:"hierarchy_file"`
+}
+
+// ReportConfig holds the branding printed on generated PDF reports
+type ReportConfig struct {
+	Organization string `yaml:"organization" toml:"organization"`
+	// LogoFile is an optional PNG or JPEG shown in the report header
+	LogoFile string `yaml:"logo_file" toml:"logo_file"`
+}
+
+// ScheduleConfig holds the scheduled report jobs and where their output is delivered
+type ScheduleConfig struct {
+	Enabled bool        `yaml:"enabled" toml:"enabled"`
+	Jobs    []JobConfig `yaml:"jobs" toml:"jobs"`
+	// OutputDir receives the files of jobs delivered to "dir"
+	OutputDir string     `yaml:"output_dir" toml:"output_dir"`
+	SMTP      SMTPConfig `yaml:"smtp" toml:"smtp"`
+}
+
+// JobConfig declares one scheduled report job run for every officer
+type JobConfig struct {
+	Name string `yaml:"name" toml:"name"`
+	// Cron is a five-field cron expression, e.g. "0 6 * * 1" for Mondays at
+	// 6am; prefix it with CRON_TZ=Asia/Manila to pin the time zone
+	Cron string `yaml:"cron" toml:"cron"`
+	// Period is the span each run reports on: "week" for the seven days before
+	// the run, "month" for the previous calendar month
+	Period string `yaml:"period" toml:"period"`
+	// Formats lists the outputs per officer: csv, pdf
+	Formats []string `yaml:"formats" toml:"formats"`
+	// Delivery is "dir" or "smtp"
+	Delivery string `yaml:"delivery" toml:"delivery"`
+}
+
+// SMTPConfig holds the mail server scheduled reports are sent through; a
+// local mail sink such as Mailpit on port 1025 works for testing
+type SMTPConfig struct {
+	Host     string   `yaml:"host" toml:"host"`
+	Port     int      `yaml:"port" toml:"port"`
+	Username string   `yaml:"username" toml:"username"`
+	Password string   `yaml:"password" toml:"password"`
+	From     string   `yaml:"from" toml:"from"`
+	To       []string `yaml:"to" toml:"to"`
+}
+
+// CacheConfig holds the dashboard response cache settings
+type CacheConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Backend is "memory" for a per-process LRU or "redis" to share entries
+	// between instances through any Redis-protocol server
+	Backend    string `yaml:"backend" toml:"backend"`
+	MaxEntries int    `yaml:"max_entries" toml:"max_entries"`
+	// DefaultTTL applies to reports without an entry in TTLs
+	DefaultTTL time.Duration `yaml:"default_ttl" toml:"default_ttl"`
+	// TTLs overrides the lifetime per report, keyed by report name (e.g. center-summary)
+	TTLs  map[string]time.Duration `yaml:"ttls" toml:"ttls"`
+	Redis RedisConfig              `yaml:"redis" toml:"redis"`
+}
+
+// RedisConfig holds the Redis connection settings for the cache
+type RedisConfig struct {
+	Addr     string `yaml:"addr" toml:"addr"`
+	Password string `yaml:"password" toml:"password"`
+	DB       int    `yaml:"db" toml:"db"`
+}
+
+// TracingConfig holds the OpenTelemetry tracing settings
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Exporter is "otlp" to send spans over OTLP/HTTP to a collector, or "stdout" to print them
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	// Endpoint is the collector's host:port for the otlp exporter
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+	// Insecure sends spans over plain HTTP, as to a local collector
+	Insecure    bool   `yaml:"insecure" toml:"insecure"`
+	ServiceName string `yaml:"service_name" toml:"service_name"`
+	// SampleRatio is the share of new traces recorded; traces started by the
+	// frontend follow the caller's sampling decision
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// APIConfig holds the settings of the versioned API routes
+type APIConfig struct {
+	// LegacyRoutes keeps serving the unversioned paths, such as
+	// /AO-dashboard/Total-loans, as deprecated aliases of their /api/v1 routes
+	LegacyRoutes bool `yaml:"legacy_routes" toml:"legacy_routes"`
+	// DeprecatedAt is the YYYY-MM-DD date announced in the Deprecation header
+	// of the legacy paths; empty leaves the header out
+	DeprecatedAt string `yaml:"deprecated_at" toml:"deprecated_at"`
+	// Sunset is the YYYY-MM-DD date after which the legacy paths may be
+	// removed, announced in the Sunset header; empty leaves the header out
+	Sunset string `yaml:"sunset" toml:"sunset"`
+}
+
+// LegacyDates returns DeprecatedAt and Sunset as dates, the zero time when
+// unset; they must have passed Validate
+func (a APIConfig) LegacyDates() (deprecatedAt, sunset time.Time) {
+	deprecatedAt, _ = time.Parse(time.DateOnly, a.DeprecatedAt)
+	sunset, _ = time.Parse(time.DateOnly, a.Sunset)
+	return deprecatedAt, sunset
+}
+
+// DSN builds the Postgres connection string from the database settings
+func (d DatabaseConfig) DSN() string {
+	parts := []string{
+		"host=" + quoteDSN(d.Host),
+		"port=" + strconv.Itoa(d.Port),
+		"user=" + quoteDSN(d.User),
+		"dbname=" + quoteDSN(d.Name),
+		"sslmode=" + d.SSLMode,
+	}
+	if d.Password != "" {
+		parts = append(parts, "password="+quoteDSN(d.Password))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteDSN quotes a key/value DSN value when it contains spaces or quotes
+func quoteDSN(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// Default returns the configuration used when nothing else is set
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			CORSOrigins:     []string{"*"},
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:              "postgres",
+			Host:                "localhost",
+			Port:                5432,
+			User:                "postgres",
+			Name:                "rbi_streamingdb",
+			SSLMode:             "disable",
+			MaxOpenConns:        25,
+			MaxIdleConns:        5,
+			ConnMaxLifetime:     30 * time.Minute,
+			ConnMaxIdleTime:     5 * time.Minute,
+			ConnectRetries:      5,
+			ConnectBackoff:      time.Second,
+			MaxConnectBackoff:   30 * time.Second,
+			HealthCheckInterval: 30 * time.Second,
+			QueryTimeout:        30 * time.Second,
+		},
+		Log: LogConfig{
+			Level:              "info",
+			SlowQueryThreshold: 500 * time.Millisecond,
+		},
+		Dashboard: DashboardConfig{
+			MaxRangeDays:   366,
+			SummaryTimeout: 15 * time.Second,
+			WeekStart:      "monday",
+			MemberStatuses: []string{"Active", "Resigned"},
+		},
+		Auth: AuthConfig{
+			Enabled:   true,
+			Algorithm: "HS256",
+			Leeway:    30 * time.Second,
+		},
+		Report: ReportConfig{
+			Organization: "RBI",
+		},
+		Schedule: ScheduleConfig{
+			Jobs: []JobConfig{{
+				Name:     "weekly",
+				Cron:     "0 6 * * 1",
+				Period:   "week",
+				Formats:  []string{"csv", "pdf"},
+				Delivery: "dir",
+			}},
+			OutputDir: "reports",
+			SMTP:      SMTPConfig{Port: 25},
+		},
+		Cache: CacheConfig{
+			Enabled:    true,
+			Backend:    "memory",
+			MaxEntries: 10000,
+			DefaultTTL: time.Minute,
+			Redis:      RedisConfig{Addr: "localhost:6379"},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "otlp",
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			ServiceName: "rbi_backend",
+			SampleRatio: 1,
+		},
+		API: APIConfig{
+			LegacyRoutes: true,
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the optional file named by
+// RBI_CONFIG_FILE and finally the RBI_* environment variables, then validates it
+func Load() (Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv("RBI_CONFIG_FILE"); path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return cfg, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile decodes a YAML or TOML file on top of cfg, chosen by extension
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: read %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config: unsupported file type %q (use .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyEnv overrides cfg with any RBI_* environment variables that are set
+func applyEnv(cfg *Config) error {
+	var errs []error
+
+	setString := func(key string, dst *string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = v
+		}
+	}
+	setInt := func(key string, dst *int) {
+		if v, ok := os.LookupEnv(key); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not an integer", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	setDuration := func(key string, dst *time.Duration) {
+		if v, ok := os.LookupEnv(key); ok {
+			d, err := time.ParseDuration(strings.TrimSpace(v))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a duration (e.g. 500ms, 2s)", key, v))
+				return
+			}
+			*dst = d
+		}
+	}
+	setBool := func(key string, dst *bool) {
+		if v, ok := os.LookupEnv(key); ok {
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a boolean", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	setFloat := func(key string, dst *float64) {
+		if v, ok := os.LookupEnv(key); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a number", key, v))
+				return
+			}
+			*dst = f
+		}
+	}
+	setList := func(key string, dst *[]string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = splitList(v)
+		}
+	}
+	setDurationMap := func(key string, dst *map[string]time.Duration) {
+		if v, ok := os.LookupEnv(key); ok {
+			m := map[string]time.Duration{}
+			for _, pair := range splitList(v) {
+				name, value, found := strings.Cut(pair, "=")
+				d, err := time.ParseDuration(strings.TrimSpace(value))
+				if !found || err != nil {
+					errs = append(errs, fmt.Errorf("%s: %q is not name=duration", key, pair))
+					continue
+				}
+				m[strings.TrimSpace(name)] = d
+			}
+			*dst = m
+		}
+	}
+
+	setString("RBI_SERVER_ADDR", &cfg.Server.Addr)
+	setDuration("RBI_SERVER_SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+	setList("RBI_CORS_ORIGINS", &cfg.Server.CORSOrigins)
+
+	setString("RBI_DB_DRIVER", &cfg.Database.Driver)
+	setString("RBI_DB_HOST", &cfg.Database.Host)
+	setInt("RBI_DB_PORT", &cfg.Database.Port)
+	setString("RBI_DB_USER", &cfg.Database.User)
+	setString("RBI_DB_PASSWORD", &cfg.Database.Password)
+	setString("RBI_DB_NAME", &cfg.Database.Name)
+	setString("RBI_DB_SSLMODE", &cfg.Database.SSLMode)
+	setInt("RBI_DB_MAX_OPEN_CONNS", &cfg.Database.MaxOpenConns)
+	setInt("RBI_DB_MAX_IDLE_CONNS", &cfg.Database.MaxIdleConns)
+	setDuration("RBI_DB_CONN_MAX_LIFETIME", &cfg.Database.ConnMaxLifetime)
+	setDuration("RBI_DB_CONN_MAX_IDLE_TIME", &cfg.Database.ConnMaxIdleTime)
+	setInt("RBI_DB_CONNECT_RETRIES", &cfg.Database.ConnectRetries)
+	setDuration("RBI_DB_CONNECT_BACKOFF", &cfg.Database.ConnectBackoff)
+	setDuration("RBI_DB_MAX_CONNECT_BACKOFF", &cfg.Database.MaxConnectBackoff)
+	setDuration("RBI_DB_HEALTH_CHECK_INTERVAL", &cfg.Database.HealthCheckInterval)
+	setDuration("RBI_DB_QUERY_TIMEOUT", &cfg.Database.QueryTimeout)
+	setDurationMap("RBI_DB_QUERY_TIMEOUTS", &cfg.Database.QueryTimeouts)
+
+	setString("RBI_LOG_LEVEL", &cfg.Log.Level)
+	setDuration("RBI_LOG_SLOW_QUERY_THRESHOLD", &cfg.Log.SlowQueryThreshold)
+
+	setInt("RBI_DASHBOARD_MAX_RANGE_DAYS", &cfg.Dashboard.MaxRangeDays)
+	setString("RBI_DASHBOARD_METRICS_FILE", &cfg.Dashboard.MetricsFile)
+	setDuration("RBI_DASHBOARD_SUMMARY_TIMEOUT", &cfg.Dashboard.SummaryTimeout)
+	setString("RBI_DASHBOARD_WEEK_START", &cfg.Dashboard.WeekStart)
+	setList("RBI_DASHBOARD_MEMBER_STATUSES", &cfg.Dashboard.MemberStatuses)
+
+	setBool("RBI_AUTH_ENABLED", &cfg.Auth.Enabled)
+	setString("RBI_AUTH_ALGORITHM", &cfg.Auth.Algorithm)
+	setString("RBI_AUTH_KEY_FILE", &cfg.Auth.KeyFile)
+	setString("RBI_AUTH_ISSUER", &cfg.Auth.Issuer)
+	setString("RBI_AUTH_AUDIENCE", &cfg.Auth.Audience)
+	setDuration("RBI_AUTH_LEEWAY", &cfg.Auth.Leeway)
+	setString("RBI_AUTH_HIERARCHY_FILE", &cfg.Auth.HierarchyFile)
+
+	setString("RBI_REPORT_ORGANIZATION", &cfg.Report.Organization)
+	setString("RBI_REPORT_LOGO_FILE", &cfg.Report.LogoFile)
+
+	setBool("RBI_SCHEDULE_ENABLED", &cfg.Schedule.Enabled)
+	setString("RBI_SCHEDULE_OUTPUT_DIR", &cfg.Schedule.OutputDir)
+	setString("RBI_SMTP_HOST", &cfg.Schedule.SMTP.Host)
+	setInt("RBI_SMTP_PORT", &cfg.Schedule.SMTP.Port)
+	setString("RBI_SMTP_USERNAME", &cfg.Schedule.SMTP.Username)
+	setString("RBI_SMTP_PASSWORD", &cfg.Schedule.SMTP.Password)
+	setString("RBI_SMTP_FROM", &cfg.Schedule.SMTP.From)
+	setList("RBI_SMTP_TO", &cfg.Schedule.SMTP.To)
+
+	setBool("RBI_CACHE_ENABLED", &cfg.Cache.Enabled)
+	setString("RBI_CACHE_BACKEND", &cfg.Cache.Backend)
+	setInt("RBI_CACHE_MAX_ENTRIES", &cfg.Cache.MaxEntries)
+	setDuration("RBI_CACHE_DEFAULT_TTL", &cfg.Cache.DefaultTTL)
+	setDurationMap("RBI_CACHE_TTLS", &cfg.Cache.TTLs)
+	setString("RBI_REDIS_ADDR", &cfg.Cache.Redis.Addr)
+	setString("RBI_REDIS_PASSWORD", &cfg.Cache.Redis.Password)
+	setInt("RBI_REDIS_DB", &cfg.Cache.Redis.DB)
+
+	setBool("RBI_TRACING_ENABLED", &cfg.Tracing.Enabled)
+	setString("RBI_TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	setString("RBI_TRACING_ENDPOINT", &cfg.Tracing.Endpoint)
+	setBool("RBI_TRACING_INSECURE", &cfg.Tracing.Insecure)
+	setString("RBI_TRACING_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	setFloat("RBI_TRACING_SAMPLE_RATIO", &cfg.Tracing.SampleRatio)
+
+	setBool("RBI_API_LEGACY_ROUTES", &cfg.API.LegacyRoutes)
+	setString("RBI_API_DEPRECATED_AT", &cfg.API.DeprecatedAt)
+	setString("RBI_API_SUNSET", &cfg.API.Sunset)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid environment: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// splitList splits a comma separated value, dropping empty entries
+func splitList(v string) []string {
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// Validate checks the configuration and reports every problem it finds
+func (c Config) Validate() error {
+	var errs []error
+
+	if strings.TrimSpace(c.Server.Addr) == "" {
+		errs = append(errs, errors.New("server.addr must not be empty"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	if len(c.Server.CORSOrigins) == 0 {
+		errs = append(errs, errors.New("server.cors_origins must list at least one origin"))
+	}
+
+	switch c.Database.Driver {
+	case "postgres", "memory":
+	default:
+		errs = append(errs, fmt.Errorf("database.driver %q must be postgres or memory", c.Database.Driver))
+	}
+	if c.Database.Host == "" {
+		errs = append(errs, errors.New("database.host must not be empty"))
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		errs = append(errs, fmt.Errorf("database.port %d is out of range 1-65535", c.Database.Port))
+	}
+	if c.Database.User == "" {
+		errs = append(errs, errors.New("database.user must not be empty"))
+	}
+	if c.Database.Name == "" {
+		errs = append(errs, errors.New("database.name must not be empty"))
+	}
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("database.sslmode %q is not a valid Postgres sslmode", c.Database.SSLMode))
+	}
+	if c.Database.MaxOpenConns < 0 {
+		errs = append(errs, errors.New("database.max_open_conns must not be negative"))
+	}
+	if c.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database.max_idle_conns must not be negative"))
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, errors.New("database.max_idle_conns must not exceed database.max_open_conns"))
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("database.conn_max_lifetime and database.conn_max_idle_time must not be negative (0 keeps connections forever)"))
+	}
+	if c.Database.ConnectRetries < 0 {
+		errs = append(errs, errors.New("database.connect_retries must not be negative"))
+	}
+	if c.Database.ConnectRetries > 0 && (c.Database.ConnectBackoff <= 0 || c.Database.MaxConnectBackoff < c.Database.ConnectBackoff) {
+		errs = append(errs, errors.New("database.connect_backoff must be positive and not exceed database.max_connect_backoff"))
+	}
+	if c.Database.HealthCheckInterval < 0 {
+		errs = append(errs, errors.New("database.health_check_interval must not be negative (0 disables health checks)"))
+	}
+	if c.Database.QueryTimeout < 0 {
+		errs = append(errs, errors.New("database.query_timeout must not be negative (0 disables the timeout)"))
+	}
+	for name, d := range c.Database.QueryTimeouts {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("database.query_timeouts.%s must not be negative (0 disables the timeout)", name))
+		}
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level %q must be one of debug, info, warn, error", c.Log.Level))
+	}
+	if c.Log.SlowQueryThreshold < 0 {
+		errs = append(errs, errors.New("log.slow_query_threshold must not be negative (0 disables slow-query logging)"))
+	}
+
+	if c.Dashboard.MaxRangeDays < 0 {
+		errs = append(errs, errors.New("dashboard.max_range_days must not be negative (0 disables the limit)"))
+	}
+	if c.Dashboard.SummaryTimeout <= 0 {
+		errs = append(errs, errors.New("dashboard.summary_timeout must be positive"))
+	}
+	if _, ok := timeseries.ParseWeekday(c.Dashboard.WeekStart); !ok {
+		errs = append(errs, fmt.Errorf("dashboard.week_start %q must be a day of the week, such as monday", c.Dashboard.WeekStart))
+	}
+
+	if c.Auth.Enabled {
+		switch c.Auth.Algorithm {
+		case "HS256", "RS256":
+		default:
+			errs = append(errs, fmt.Errorf("auth.algorithm %q must be HS256 or RS256", c.Auth.Algorithm))
+		}
+		if c.Auth.KeyFile == "" {
+			errs = append(errs, errors.New("auth.key_file is required when auth is enabled; set RBI_AUTH_ENABLED=false to run locally without tokens"))
+		}
+		if c.Auth.Leeway < 0 {
+			errs = append(errs, errors.New("auth.leeway must not be negative"))
+		}
+	}
+
+	if strings.TrimSpace(c.Report.Organization) == "" {
+		errs = append(errs, errors.New("report.organization must not be empty"))
+	}
+	if c.Report.LogoFile != "" {
+		switch strings.ToLower(filepath.Ext(c.Report.LogoFile)) {
+		case ".png", ".jpg", ".jpeg":
+		default:
+			errs = append(errs, fmt.Errorf("report.logo_file %q must be a PNG or JPEG image", c.Report.LogoFile))
+		}
+	}
+
+	if c.Schedule.Enabled {
+		errs = append(errs, c.Schedule.validate()...)
+	}
+
+	if c.Cache.Enabled {
+		switch c.Cache.Backend {
+		case "memory":
+			if c.Cache.MaxEntries < 1 {
+				errs = append(errs, errors.New("cache.max_entries must be at least 1"))
+			}
+		case "redis":
+			if c.Cache.Redis.Addr == "" {
+				errs = append(errs, errors.New("cache.redis.addr is required for the redis backend"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("cache.backend %q must be memory or redis", c.Cache.Backend))
+		}
+		if c.Cache.DefaultTTL < 0 {
+			errs = append(errs, errors.New("cache.default_ttl must not be negative (0 disables caching by default)"))
+		}
+		for name, ttl := range c.Cache.TTLs {
+			if ttl < 0 {
+				errs = append(errs, fmt.Errorf("cache.ttls.%s must not be negative (0 disables caching)", name))
+			}
+		}
+	}
+
+	if c.Tracing.Enabled {
+		switch c.Tracing.Exporter {
+		case "otlp":
+			if strings.TrimSpace(c.Tracing.Endpoint) == "" {
+				errs = append(errs, errors.New("tracing.endpoint is required for the otlp exporter"))
+			}
+		case "stdout":
+		default:
+			errs = append(errs, fmt.Errorf("tracing.exporter %q must be otlp or stdout", c.Tracing.Exporter))
+		}
+		if strings.TrimSpace(c.Tracing.ServiceName) == "" {
+			errs = append(errs, errors.New("tracing.service_name must not be empty"))
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			errs = append(errs, fmt.Errorf("tracing.sample_ratio %g must be between 0 and 1", c.Tracing.SampleRatio))
+		}
+	}
+
+	if c.API.LegacyRoutes {
+		deprecated, err := time.Parse(time.DateOnly, c.API.DeprecatedAt)
+		if c.API.DeprecatedAt != "" && err != nil {
+			errs = append(errs, fmt.Errorf("api.deprecated_at %q is not a date (expected YYYY-MM-DD)", c.API.DeprecatedAt))
+		}
+		sunset, err := time.Parse(time.DateOnly, c.API.Sunset)
+		if c.API.Sunset != "" && err != nil {
+			errs = append(errs, fmt.Errorf("api.sunset %q is not a date (expected YYYY-MM-DD)", c.API.Sunset))
+		}
+		if !deprecated.IsZero() && !sunset.IsZero() && sunset.Before(deprecated) {
+			errs = append(errs, errors.New("api.sunset must not be before api.deprecated_at"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// jobNamePattern restricts job names, which become output directory names
+var jobNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// validate checks the scheduled jobs and the delivery settings they use
+func (s ScheduleConfig) validate() []error {
+	var errs []error
+
+	names := map[string]bool{}
+	usesDir, usesSMTP := false, false
+	for i, job := range s.Jobs {
+		field := fmt.Sprintf("schedule.jobs[%d]", i)
+		if !jobNamePattern.MatchString(job.Name) {
+			errs = append(errs, fmt.Errorf("%s.name %q must be lowercase letters, digits, - or _", field, job.Name))
+		} else if names[job.Name] {
+			errs = append(errs, fmt.Errorf("%s.name %q is used by another job", field, job.Name))
+		}
+		names[job.Name] = true
+
+		if _, err := cron.ParseStandard(job.Cron); err != nil {
+			errs = append(errs, fmt.Errorf("%s.cron %q is invalid: %v", field, job.Cron, err))
+		}
+		switch job.Period {
+		case "week", "month":
+		default:
+			errs = append(errs, fmt.Errorf("%s.period %q must be week or month", field, job.Period))
+		}
+		if len(job.Formats) == 0 {
+			errs = append(errs, fmt.Errorf("%s.formats must list at least one format", field))
+		}
+		for _, format := range job.Formats {
+			if format != "csv" && format != "pdf" {
+				errs = append(errs, fmt.Errorf("%s.formats: %q must be csv or pdf", field, format))
+			}
+		}
+		switch job.Delivery {
+		case "dir":
+			usesDir = true
+		case "smtp":
+			usesSMTP = true
+		default:
+			errs = append(errs, fmt.Errorf("%s.delivery %q must be dir or smtp", field, job.Delivery))
+		}
+	}
+	if len(s.Jobs) == 0 {
+		errs = append(errs, errors.New("schedule.jobs must list at least one job when the schedule is enabled"))
+	}
+
+	if usesDir && s.OutputDir == "" {
+		errs = append(errs, errors.New("schedule.output_dir is required for dir delivery"))
+	}
+	if usesSMTP {
+		if s.SMTP.Host == "" {
+			errs = append(errs, errors.New("schedule.smtp.host is required for smtp delivery"))
+		}
+		if s.SMTP.Port < 1 || s.SMTP.Port > 65535 {
+			errs = append(errs, fmt.Errorf("schedule.smtp.port %d is out of range 1-65535", s.SMTP.Port))
+		}
+		if s.SMTP.From == "" {
+			errs = append(errs, errors.New("schedule.smtp.from is required for smtp delivery"))
+		}
+		if len(s.SMTP.To) == 0 {
+			errs = append(errs, errors.New("schedule.smtp.to must list at least one recipient for smtp delivery"))
+		}
+	}
+	return errs
+}
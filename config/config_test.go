@@ -0,0 +1,178 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// local ignores any config file and disables authentication so the defaults load
+func local(t *testing.T) {
+	t.Helper()
+	t.Setenv("RBI_CONFIG_FILE", "")
+	t.Setenv("RBI_AUTH_ENABLED", "false")
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		// want edits the defaults into the expected configuration
+		want func(*Config)
+	}{
+		{"defaults", nil, func(*Config) {}},
+		{"string", map[string]string{"RBI_SERVER_ADDR": ":9000"}, func(c *Config) { c.Server.Addr = ":9000" }},
+		{"integer", map[string]string{"RBI_DB_PORT": " 6432 "}, func(c *Config) { c.Database.Port = 6432 }},
+		{"duration", map[string]string{"RBI_DB_QUERY_TIMEOUT": "2s"}, func(c *Config) { c.Database.QueryTimeout = 2 * time.Second }},
+		{"boolean", map[string]string{"RBI_SCHEDULE_ENABLED": "true", "RBI_CACHE_ENABLED": "0"}, func(c *Config) {
+			c.Schedule.Enabled = true
+			c.Cache.Enabled = false
+		}},
+		{"list dropping empty entries", map[string]string{"RBI_CORS_ORIGINS": "https://a.example, ,https://b.example,"}, func(c *Config) {
+			c.Server.CORSOrigins = []string{"https://a.example", "https://b.example"}
+		}},
+		{"duration map", map[string]string{"RBI_DB_QUERY_TIMEOUTS": "summary=10s, clients=1m"}, func(c *Config) {
+			c.Database.QueryTimeouts = map[string]time.Duration{"summary": 10 * time.Second, "clients": time.Minute}
+		}},
+		{"member statuses", map[string]string{"RBI_DASHBOARD_MEMBER_STATUSES": "Active,Dormant"}, func(c *Config) {
+			c.Dashboard.MemberStatuses = []string{"Active", "Dormant"}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			want := Default()
+			want.Auth.Enabled = false
+			tt.want(&want)
+
+			got, err := Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load with %v = %+v, want %+v", tt.env, got, want)
+			}
+		})
+	}
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	local(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  addr: \":7000\"\ndatabase:\n  port: 6000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RBI_CONFIG_FILE", path)
+	t.Setenv("RBI_DB_PORT", "6543")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Addr != ":7000" || cfg.Database.Port != 6543 {
+		t.Errorf("Load = addr %s, port %d, want the file's addr :7000 and the environment's port 6543", cfg.Server.Addr, cfg.Database.Port)
+	}
+}
+
+func TestLoadRejectsMalformedEnv(t *testing.T) {
+	local(t)
+	env := map[string]string{
+		"RBI_DB_PORT":           "five",
+		"RBI_DB_QUERY_TIMEOUT":  "30",
+		"RBI_CACHE_ENABLED":     "maybe",
+		"RBI_DB_QUERY_TIMEOUTS": "summary=10s,clients",
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load accepted malformed environment variables")
+	}
+	for _, want := range []string{
+		`RBI_DB_PORT: "five" is not an integer`,
+		`RBI_DB_QUERY_TIMEOUT: "30" is not a duration`,
+		`RBI_CACHE_ENABLED: "maybe" is not a boolean`,
+		`RBI_DB_QUERY_TIMEOUTS: "clients" is not name=duration`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// valid returns the defaults with authentication configured
+	valid := func() Config {
+		c := Default()
+		c.Auth.KeyFile = "/etc/rbi/jwt.key"
+		return c
+	}
+	tests := []struct {
+		name string
+		edit func(*Config)
+		// errs are fragments of the expected errors, every one reported at once
+		errs []string
+	}{
+		{"defaults with a key file", func(*Config) {}, nil},
+		{"auth without a key file", func(c *Config) { c.Auth.KeyFile = "" }, []string{"auth.key_file is required when auth is enabled"}},
+		{"auth disabled without a key file", func(c *Config) { c.Auth.Enabled, c.Auth.KeyFile = false, "" }, nil},
+		{"server", func(c *Config) {
+			c.Server.Addr = " "
+			c.Server.ShutdownTimeout = 0
+			c.Server.CORSOrigins = nil
+		}, []string{"server.addr must not be empty", "server.shutdown_timeout must be positive", "server.cors_origins must list at least one origin"}},
+		{"database", func(c *Config) {
+			c.Database.Driver = "mysql"
+			c.Database.Port = 70000
+			c.Database.SSLMode = "on"
+			c.Database.MaxOpenConns, c.Database.MaxIdleConns = 5, 10
+		}, []string{`database.driver "mysql"`, "database.port 70000 is out of range", `database.sslmode "on"`, "database.max_idle_conns must not exceed"}},
+		{"dashboard", func(c *Config) {
+			c.Dashboard.MaxRangeDays = -1
+			c.Dashboard.SummaryTimeout = 0
+			c.Dashboard.WeekStart = "someday"
+		}, []string{"dashboard.max_range_days must not be negative", "dashboard.summary_timeout must be positive", `dashboard.week_start "someday"`}},
+		{"log", func(c *Config) { c.Log.Level = "verbose" }, []string{`log.level "verbose"`}},
+		{"cache backend", func(c *Config) { c.Cache.Enabled, c.Cache.Backend = true, "memcached" }, []string{`cache.backend "memcached"`}},
+		{"cache ignored while disabled", func(c *Config) { c.Cache.Enabled, c.Cache.Backend = false, "memcached" }, nil},
+		{"legacy dates", func(c *Config) { c.API.DeprecatedAt, c.API.Sunset = "2025-13-01", "soon" }, []string{`api.deprecated_at "2025-13-01"`, `api.sunset "soon"`}},
+		{"sunset before deprecation", func(c *Config) { c.API.DeprecatedAt, c.API.Sunset = "2025-06-01", "2025-01-01" }, []string{"api.sunset must not be before api.deprecated_at"}},
+		{"sunset alone", func(c *Config) { c.API.Sunset = "2025-01-01" }, nil},
+		{"scheduled job", func(c *Config) {
+			c.Schedule.Enabled = true
+			c.Schedule.Jobs = []JobConfig{{Name: "Weekly Run", Cron: "every monday", Period: "day", Delivery: "dir"}}
+		}, []string{`schedule.jobs[0].name "Weekly Run"`, `schedule.jobs[0].cron "every monday"`, `schedule.jobs[0].period "day"`, "schedule.jobs[0].formats must list at least one format"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.edit(&c)
+			err := c.Validate()
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate accepted the configuration, want %v", tt.errs)
+			}
+			for _, want := range tt.errs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate error %q does not mention %s", err, want)
+				}
+			}
+			if n := strings.Count(err.Error(), "\n") + 1; n != len(tt.errs) {
+				t.Errorf("Validate reported %d errors, want %d:\n%v", n, len(tt.errs), err)
+			}
+		})
+	}
+}
@@ -1,7 +1,16 @@
 package database
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"rbi_backend/config"
+	"rbi_backend/logging"
+	"rbi_backend/monitoring"
+	"rbi_backend/tracing"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -10,19 +19,97 @@ import (
 // DB is the database instance
 var DB *gorm.DB
 
-// Connect initializes the database connection
-func Connect() error {
-	var err error
-	// Adjust the DSN string with your actual database credentials and details
-	dsn := "host=data-platform-db.fortress-asya.com user=postgres password=P@ssw0rd1qaz2wsx3edc dbname=rbi_streamingdb port=5432 sslmode=disable" // Update this line
+// Connect initializes the database connection, retrying a failed attempt with
+// exponential backoff, and starts the periodic health check
+func Connect(ctx context.Context, cfg config.DatabaseConfig, logCfg config.LogConfig) error {
+	db, err := open(ctx, cfg, logCfg)
+	if err != nil {
+		slog.Error("Failed to connect to the database", slog.String("error", err.Error()))
+		return err
+	}
+	DB = db
 
-	// Open a connection to the database
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Fatal("Failed to connect to the database:", err)
-		return err // Return the error for further handling if needed
+		return err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	// Time and trace every statement and expose the pool statistics
+	if err := DB.Use(monitoring.GormPlugin{}); err != nil {
+		return err
+	}
+	if err := DB.Use(tracing.GormPlugin{Database: cfg.Name}); err != nil {
+		return err
+	}
+	if err := monitoring.RegisterDB(sqlDB, cfg.Name); err != nil {
+		return err
+	}
+
+	health.set(nil)
+	if cfg.HealthCheckInterval > 0 {
+		startHealthCheck(sqlDB, cfg.HealthCheckInterval)
 	}
 
-	log.Println("Database connection established successfully!")
+	slog.Info("Database connection established successfully", slog.String("host", cfg.Host), slog.String("dbname", cfg.Name))
 	return nil
 }
+
+// open opens GORM, which pings the server, until it succeeds, the retries are
+// used up or ctx ends
+func open(ctx context.Context, cfg config.DatabaseConfig, logCfg config.LogConfig) (*gorm.DB, error) {
+	backoff := cfg.ConnectBackoff
+	for attempt := 0; ; attempt++ {
+		// Open a connection to the database, logging SQL through slog
+		db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+			Logger: logging.NewGormLogger(logCfg.SlowQueryThreshold),
+		})
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= cfg.ConnectRetries {
+			return nil, err
+		}
+
+		slog.Warn("Database not reachable, retrying",
+			slog.Int("attempt", attempt+1),
+			slog.String("backoff", backoff.String()),
+			slog.String("error", err.Error()))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		}
+		backoff = min(backoff*2, cfg.MaxConnectBackoff)
+	}
+}
+
+// Stats returns the connection pool statistics, or zero values before Connect
+func Stats() sql.DBStats {
+	if DB == nil {
+		return sql.DBStats{}
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
+// Close stops the health check and closes the pool once the queries in
+// flight have finished
+func Close() error {
+	stopHealthCheck()
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	slog.Info("Closing the database connection pool", slog.Int("in_use", sqlDB.Stats().InUse))
+	return sqlDB.Close()
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// pingTimeout bounds a single health ping
+const pingTimeout = 5 * time.Second
+
+// ErrNotConnected is reported by Ping and Health before Connect succeeds
+var ErrNotConnected = errors.New("database not connected")
+
+// Status is the outcome of the latest health ping
+type Status struct {
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	// Since is when the database last turned healthy or unhealthy
+	Since time.Time `json:"since"`
+}
+
+// healthState is the shared Status, updated by the health check
+type healthState struct {
+	mu     sync.RWMutex
+	status Status
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+var health = &healthState{status: Status{Error: ErrNotConnected.Error()}}
+
+// set records a ping result, logging when the database goes down or comes back
+func (h *healthState) set(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	healthy := err == nil
+	if healthy != h.status.Healthy || h.status.Since.IsZero() {
+		h.status.Since = now
+		switch {
+		case !healthy:
+			slog.Error("Database health check failed", slog.String("error", err.Error()))
+		case !h.status.CheckedAt.IsZero():
+			slog.Info("Database connection recovered")
+		}
+	}
+	h.status.Healthy = healthy
+	h.status.CheckedAt = now
+	h.status.Error = ""
+	if err != nil {
+		h.status.Error = err.Error()
+	}
+}
+
+// Health returns the outcome of the latest health ping
+func Health() Status {
+	health.mu.RLock()
+	defer health.mu.RUnlock()
+	return health.status
+}
+
+// Ping checks the database now and records the result
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return ErrNotConnected
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	err = sqlDB.PingContext(ctx)
+	health.set(err)
+	return err
+}
+
+// startHealthCheck pings the pool every interval. database/sql replaces
+// broken connections by itself, so the check only has to notice and report an
+// outage and the recovery.
+func startHealthCheck(sqlDB *sql.DB, interval time.Duration) {
+	stopHealthCheck()
+
+	health.mu.Lock()
+	stop, done := make(chan struct{}), make(chan struct{})
+	health.stop, health.done = stop, done
+	health.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+				health.set(sqlDB.PingContext(ctx))
+				cancel()
+
+				stats := sqlDB.Stats()
+				slog.Debug("Database pool",
+					slog.Int("open", stats.OpenConnections),
+					slog.Int("in_use", stats.InUse),
+					slog.Int("idle", stats.Idle),
+					slog.Int64("wait_count", stats.WaitCount),
+					slog.String("wait_duration", stats.WaitDuration.String()))
+			}
+		}
+	}()
+}
+
+// stopHealthCheck stops a running health check and waits for it to exit
+func stopHealthCheck() {
+	health.mu.Lock()
+	stop, done := health.stop, health.done
+	health.stop, health.done = nil, nil
+	health.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// TableExists reports whether a table or view exists, as in public.customer_info
+func TableExists(ctx context.Context, name string) (bool, error) {
+	if DB == nil {
+		return false, ErrNotConnected
+	}
+	var exists bool
+	err := DB.WithContext(ctx).Raw(`SELECT to_regclass(?) IS NOT NULL`, name).Scan(&exists).Error
+	return exists, err
+}
+
+// FunctionExists reports whether a function of that name exists in the public schema
+func FunctionExists(ctx context.Context, name string) (bool, error) {
+	if DB == nil {
+		return false, ErrNotConnected
+	}
+	var exists bool
+	err := DB.WithContext(ctx).Raw(`SELECT EXISTS (
+		SELECT 1 FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = 'public' AND p.proname = ?)`, name).Scan(&exists).Error
+	return exists, err
+}
@@ -0,0 +1,88 @@
+// Package deadline bounds the database work of a request: each report or
+// metric gets a timeout, so aggregates abandoned by a client that went away
+// stop running on the server once it passes. fasthttp does not signal a closed
+// connection while the handler runs, so the timeout is the only bound.
+package deadline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"rbi_backend/apperr"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Timeouts holds the query timeout of every report and metric
+type Timeouts struct {
+	def       time.Duration
+	overrides map[string]time.Duration
+}
+
+// New returns Timeouts applying def unless overrides names the report or
+// metric; a zero timeout leaves its queries unbounded
+func New(def time.Duration, overrides map[string]time.Duration) *Timeouts {
+	return &Timeouts{def: def, overrides: overrides}
+}
+
+// For is middleware running the rest of the chain under the timeout of one
+// report. The stores pass the request context to every query, and pgx cancels
+// a statement on the server when its context ends.
+func (t *Timeouts) For(name string) fiber.Handler {
+	return t.handler(func(*fiber.Ctx) string { return name })
+}
+
+// ForParam is For with the metric named by a route parameter, as in /metrics/{name}
+func (t *Timeouts) ForParam(param string) fiber.Handler {
+	return t.handler(func(c *fiber.Ctx) string { return c.Params(param) })
+}
+
+// timeout returns the timeout configured for name
+func (t *Timeouts) timeout(name string) time.Duration {
+	if d, ok := t.overrides[name]; ok {
+		return d
+	}
+	return t.def
+}
+
+func (t *Timeouts) handler(name func(*fiber.Ctx) string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		metric := name(c)
+		timeout := t.timeout(metric)
+
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		parent := c.UserContext()
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+		c.SetUserContext(parent)
+
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return &apperr.Error{
+				Status:  fiber.StatusGatewayTimeout,
+				Code:    apperr.CodeTimeout,
+				Message: fmt.Sprintf("The %s query did not finish within %s", metric, timeout),
+				Err:     err,
+			}
+		}
+		return err
+	}
+}
+
+// Detach returns a context with the values and deadline of ctx but not its
+// cancellation, for work that outlives the handler such as a streamed body.
+// The caller must call cancel once that work is done.
+func Detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	if d, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, d)
+	}
+	return context.WithCancel(detached)
+}
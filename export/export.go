@@ -0,0 +1,110 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"rbi_backend/apperr"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// formatKey is the c.Locals key holding the negotiated Format
+const formatKey = "exportFormat"
+
+// Format is the representation a dashboard report is returned in
+type Format string
+
+const (
+	JSON Format = "json"
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+	// PDF is only produced by the monthly report and is never negotiated
+	PDF Format = "pdf"
+)
+
+// MIME types of the spreadsheet formats
+const (
+	MIMECSV  = "text/csv; charset=utf-8"
+	MIMEXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// ContentType returns the Content-Type header value for the format
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return MIMECSV
+	case XLSX:
+		return MIMEXLSX
+	case PDF:
+		return "application/pdf"
+	}
+	return fiber.MIMEApplicationJSONCharsetUTF8
+}
+
+// Negotiate picks the response format from the format query parameter, which
+// wins when present, or else from the Accept header, defaulting to JSON
+func Negotiate(c *fiber.Ctx) (Format, error) {
+	if v := strings.ToLower(strings.TrimSpace(c.Query("format"))); v != "" {
+		switch f := Format(v); f {
+		case JSON, CSV, XLSX:
+			return f, nil
+		}
+		return "", apperr.Validation("Invalid request parameters", []apperr.FieldError{
+			{Field: "format", Message: fmt.Sprintf("%q is not supported (expected json, csv or xlsx)", v)},
+		})
+	}
+
+	switch c.Accepts(fiber.MIMEApplicationJSON, "text/csv", MIMEXLSX) {
+	case "text/csv":
+		return CSV, nil
+	case MIMEXLSX:
+		return XLSX, nil
+	}
+	return JSON, nil
+}
+
+// Middleware negotiates the response format for every route in a group,
+// failing with a validation error for an unknown ?format= value
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		f, err := Negotiate(c)
+		if err != nil {
+			return err
+		}
+
+		c.Vary(fiber.HeaderAccept)
+		c.Locals(formatKey, f)
+		return c.Next()
+	}
+}
+
+// FromCtx returns the format negotiated by Middleware, JSON if it did not run
+func FromCtx(c *fiber.Ctx) Format {
+	if f, ok := c.Locals(formatKey).(Format); ok {
+		return f
+	}
+	return JSON
+}
+
+// unsafeFilename matches the characters replaced in generated filenames
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Filename builds the download name for a report, e.g.
+// center-summary_JDELACRUZ_2024-01-01_2024-01-31.xlsx; subject is the
+// officer, unit, center or branch the report covers, "all" when empty, and
+// period is its start and end date or month
+func Filename(f Format, report, subject string, period ...string) string {
+	if subject == "" {
+		subject = "all"
+	}
+	name := strings.Join(append([]string{report, subject}, period...), "_")
+	return unsafeFilename.ReplaceAllString(name, "-") + "." + string(f)
+}
+
+// Attach sets the Content-Type and Content-Disposition headers for a download
+func Attach(c *fiber.Ctx, filename string, f Format) {
+	c.Set(fiber.HeaderContentType, f.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"io"
+
+	"rbi_backend/store"
+)
+
+// Report describes how a dashboard result is laid out as a spreadsheet
+type Report[T any] struct {
+	// Name prefixes the download filename
+	Name string
+	// Sheet names the XLSX worksheet
+	Sheet   string
+	Columns []Column
+	Row     func(T) []any
+}
+
+// Spreadsheet layouts of the dashboard reports
+var (
+	TotalValues = Report[store.Result]{
+		Name:    "total-values",
+		Sheet:   "Total Values",
+		Columns: []Column{{Header: "Particulars"}, {Header: "Count", Kind: Integer}},
+		Row:     func(r store.Result) []any { return []any{r.Particulars, r.Count} },
+	}
+	LoanTotals = Report[store.LoanAccountResult]{
+		Name:    "loan-totals",
+		Sheet:   "Loan Totals",
+		Columns: []Column{{Header: "Particulars"}, {Header: "Count", Kind: Integer}, {Header: "Amount", Kind: Amount}},
+		Row:     func(r store.LoanAccountResult) []any { return []any{r.Particulars, r.Count, r.Amount} },
+	}
+	CapitalBuildUp = Report[store.CapitalBuildUpResult]{
+		Name:    "capital-build-up",
+		Sheet:   "Capital Build-Up",
+		Columns: []Column{{Header: "Title"}, {Header: "Total Capital", Kind: Amount}},
+		Row:     func(r store.CapitalBuildUpResult) []any { return []any{r.Title, r.TotalCapital} },
+	}
+	AgeGroups = Report[store.AgeGroupCount]{
+		Name:  "age-groups",
+		Sheet: "Age Groups",
+		Columns: []Column{
+			{Header: "18-29", Kind: Integer},
+			{Header: "30-39", Kind: Integer},
+			{Header: "40-49", Kind: Integer},
+			{Header: "50-59", Kind: Integer},
+			{Header: "60-69", Kind: Integer},
+			{Header: "70-79", Kind: Integer},
+			{Header: "80+", Kind: Integer},
+			{Header: "Total", Kind: Integer},
+		},
+		Row: func(r store.AgeGroupCount) []any {
+			return []any{r.Age18_29, r.Age30_39, r.Age40_49, r.Age50_59, r.Age60_69, r.Age70_79, r.Age80Plus, r.Total}
+		},
+	}
+	ProductCounts = Report[store.ProductCount]{
+		Name:    "product-counts",
+		Sheet:   "Product Counts",
+		Columns: []Column{{Header: "Product Name"}, {Header: "Count", Kind: Integer}},
+		Row:     func(r store.ProductCount) []any { return []any{r.ProductName, r.Count} },
+	}
+	CenterSummary = Report[store.CenterSummary]{
+		Name:  "center-summary",
+		Sheet: "Center Summary",
+		Columns: []Column{
+			{Header: "Center Name"},
+			{Header: "No. of Clients", Kind: Integer},
+			{Header: "With Loans", Kind: Integer},
+			{Header: "Without Loans", Kind: Integer},
+			{Header: "Past Due", Kind: Integer},
+		},
+		Row: func(r store.CenterSummary) []any {
+			return []any{r.CenterName, r.NoOfClients, r.WithLoans, r.WithoutLoans, r.PastDue}
+		},
+	}
+	WeeklyCounts = Report[store.WeeklyCount]{
+		Name:    "weekly-client-count",
+		Sheet:   "Weekly Client Count",
+		Columns: []Column{{Header: "Particulars"}, {Header: "Week"}, {Header: "Count", Kind: Integer}},
+		Row:     func(r store.WeeklyCount) []any { return []any{r.Particulars, r.Week, r.Count} },
+	}
+	WeeklyCapital = Report[store.WeeklyCapitalBuildUp]{
+		Name:    "weekly-capital-build-up",
+		Sheet:   "Weekly Capital Build-Up",
+		Columns: []Column{{Header: "Title"}, {Header: "Week"}, {Header: "Total Capital", Kind: Amount}},
+		Row:     func(r store.WeeklyCapitalBuildUp) []any { return []any{r.Title, r.Week, r.TotalCapital} },
+	}
+	// PeriodCounts and PeriodCapital lay out the trend reports once a
+	// granularity is requested, with the dates of each period
+	PeriodCounts = Report[store.WeeklyCount]{
+		Name:    "weekly-client-count",
+		Sheet:   "Weekly Client Count",
+		Columns: []Column{{Header: "Particulars"}, {Header: "Period"}, {Header: "Period Start"}, {Header: "Period End"}, {Header: "Count", Kind: Integer}},
+		Row: func(r store.WeeklyCount) []any {
+			return []any{r.Particulars, r.Week, r.PeriodStart, r.PeriodEnd, r.Count}
+		},
+	}
+	PeriodCapital = Report[store.WeeklyCapitalBuildUp]{
+		Name:    "weekly-capital-build-up",
+		Sheet:   "Weekly Capital Build-Up",
+		Columns: []Column{{Header: "Title"}, {Header: "Period"}, {Header: "Period Start"}, {Header: "Period End"}, {Header: "Total Capital", Kind: Amount}},
+		Row: func(r store.WeeklyCapitalBuildUp) []any {
+			return []any{r.Title, r.Week, r.PeriodStart, r.PeriodEnd, r.TotalCapital}
+		},
+	}
+	Clients = Report[store.ActiveClientInfo]{
+		Name:  "clients",
+		Sheet: "Clients",
+		Columns: []Column{
+			{Header: "Unit Name"},
+			{Header: "Center Name"},
+			{Header: "CID"},
+			{Header: "Client Name"},
+			{Header: "Date Recognized"},
+			{Header: "Member Status"},
+		},
+		Row: func(r store.ActiveClientInfo) []any {
+			return []any{r.UnitName, r.CenterName, r.CID, r.ClientName, r.DateRecognized, r.MemberStatus}
+		},
+	}
+)
+
+// WriteAll writes every result as a CSV or XLSX document
+func (r Report[T]) WriteAll(f Format, w io.Writer, results []T) error {
+	ew, err := NewWriter(f, w, r.Sheet, r.Columns)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if err := ew.Write(r.Row(result)); err != nil {
+			return err
+		}
+	}
+	return ew.Close()
+}
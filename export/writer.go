@@ -0,0 +1,181 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Kind is how a column's values are formatted in a spreadsheet
+type Kind int
+
+const (
+	Text Kind = iota
+	Integer
+	// Amount is a peso amount such as online_actual_bal, shown with two decimals
+	Amount
+)
+
+// Column is one column of an exported report
+type Column struct {
+	Header string
+	Kind   Kind
+}
+
+// Writer writes the rows of a report in a spreadsheet format; values must be
+// given in column order
+type Writer interface {
+	Write(row []any) error
+	// Close flushes any buffered output; the Writer must not be used afterwards
+	Close() error
+}
+
+// NewWriter returns a Writer for the CSV or XLSX format that has already
+// written the header row; sheet names the worksheet of an XLSX workbook
+func NewWriter(f Format, w io.Writer, sheet string, columns []Column) (Writer, error) {
+	switch f {
+	case CSV:
+		return newCSVWriter(w, columns)
+	case XLSX:
+		return newXLSXWriter(w, sheet, columns)
+	}
+	return nil, fmt.Errorf("export: format %q has no spreadsheet writer", f)
+}
+
+// csvWriter writes RFC 4180 CSV
+type csvWriter struct {
+	w       *csv.Writer
+	columns []Column
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	// A UTF-8 byte order mark makes Excel read client names with ñ correctly
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+
+	cw := &csvWriter{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+	for i, col := range columns {
+		cw.record[i] = col.Header
+	}
+	return cw, cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Write(row []any) error {
+	for i, col := range cw.columns {
+		cw.record[i] = formatCSV(col.Kind, row[i])
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// formatCSV renders a value for a CSV cell; amounts keep two decimals and no
+// thousands separator so spreadsheets still read them as numbers
+func formatCSV(kind Kind, v any) string {
+	if kind == Amount {
+		if x, ok := v.(float64); ok {
+			return strconv.FormatFloat(x, 'f', 2, 64)
+		}
+	}
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// xlsxWriter streams rows into a single-sheet workbook
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	styles []int
+	row    int
+	cells  []any
+}
+
+// Built-in Excel number formats
+const (
+	numFmtInteger = 3 // #,##0
+	numFmtAmount  = 4 // #,##0.00
+)
+
+func newXLSXWriter(w io.Writer, sheet string, columns []Column) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	xw := &xlsxWriter{out: w, file: file, styles: make([]int, len(columns)), cells: make([]any, len(columns))}
+	if err := xw.init(sheet, columns); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return xw, nil
+}
+
+// init creates the column styles and writes the bold header row
+func (xw *xlsxWriter) init(sheet string, columns []Column) error {
+	header, err := xw.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	integer, err := xw.file.NewStyle(&excelize.Style{NumFmt: numFmtInteger})
+	if err != nil {
+		return err
+	}
+	amount, err := xw.file.NewStyle(&excelize.Style{NumFmt: numFmtAmount})
+	if err != nil {
+		return err
+	}
+
+	if xw.stream, err = xw.file.NewStreamWriter(sheet); err != nil {
+		return err
+	}
+	if err := xw.stream.SetColWidth(1, len(columns), 20); err != nil {
+		return err
+	}
+
+	for i, col := range columns {
+		switch col.Kind {
+		case Integer:
+			xw.styles[i] = integer
+		case Amount:
+			xw.styles[i] = amount
+		}
+		xw.cells[i] = excelize.Cell{StyleID: header, Value: col.Header}
+	}
+	if err := xw.stream.SetRow("A1", xw.cells, excelize.RowOpts{StyleID: header}); err != nil {
+		return err
+	}
+	xw.row = 1
+	return nil
+}
+
+func (xw *xlsxWriter) Write(row []any) error {
+	xw.row++
+	for i, v := range row {
+		xw.cells[i] = excelize.Cell{StyleID: xw.styles[i], Value: v}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	return xw.stream.SetRow(cell, xw.cells)
+}
+
+func (xw *xlsxWriter) Close() error {
+	defer xw.file.Close()
+	if err := xw.stream.Flush(); err != nil {
+		return err
+	}
+	return xw.file.Write(xw.out)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+
+	"rbi_backend/apperr"
+	"rbi_backend/comparison"
+	"rbi_backend/handlers/params"
+	"rbi_backend/logging"
+	"rbi_backend/store"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+// query is a store method computing an aggregate report
+type query[T any] func(context.Context, store.Filter) ([]T, error)
+
+// sendComparison runs q over the requested range and the range it is compared
+// against under m concurrently, and sends how every value changed between them
+func sendComparison[T any](c *fiber.Ctx, m comparison.Mode, q query[T], message string) error {
+	f := params.Filter(c)
+	against := f.Compared(m)
+
+	var current, previous []T
+	g, ctx := errgroup.WithContext(c.UserContext())
+	g.Go(func() (err error) {
+		current, err = q(ctx, f.StoreFilter())
+		return err
+	})
+	g.Go(func() (err error) {
+		previous, err = q(ctx, against.StoreFilter())
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return apperr.Wrap(err, message)
+	}
+
+	rows := comparison.Compare(comparison.Records(current), comparison.Records(previous))
+	logging.SetRows(c, len(rows))
+	return c.JSON(comparison.Report{
+		Compare:          m,
+		Period:           f.Period(),
+		ComparisonPeriod: against.Period(),
+		Rows:             rows,
+	})
+}
+
+// single adapts a store method returning one row to a query
+func single[T any](q func(context.Context, store.Filter) (T, error)) query[T] {
+	return func(ctx context.Context, f store.Filter) ([]T, error) {
+		result, err := q(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		return []T{result}, nil
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rbi_backend/apperr"
+	"rbi_backend/export"
+	"rbi_backend/handlers/params"
+	"rbi_backend/store"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// seed returns a memory store with clients of two officers in March 2024
+func seed() *store.MemoryStore {
+	return store.NewMemoryStore([]store.Customer{
+		{TID: "C1", CustomerName: "Ana Cruz", AccountOfficer: "ao1", UnitName: "U1", CenterName: "CA", MemberStatus: "Active", DateRecognized: date("2024-03-04"), DateOfBirth: date("1990-06-01")},
+		{TID: "C2", CustomerName: "Ben Diaz", AccountOfficer: "ao2", UnitName: "U1", CenterName: "CB", MemberStatus: "Resigned", DateRecognized: date("2024-03-10"), DateOfBirth: date("1980-01-01")},
+	}, []store.LoanAccount{
+		{Customer: "C1", AccountOfficer: "ao1", BillType: "Regular", BillStatus: "DUE", AccountTitle: "Micro Loan", OpeningDate: date("2024-03-04"), OnlineActualBal: -1000},
+	})
+}
+
+// newApp mounts the reports of a level under /dashboard as the route table
+// does, with the error handler main installs
+func newApp(h *Handler, level params.Level) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+	g := app.Group("/dashboard", params.RequireDashboardFilter(level, params.Options{MaxRangeDays: 366}), export.Middleware())
+	g.Get("/", h.GetTotalCountsClient)
+	g.Get("/capital", h.GetCapitalBuildUp)
+	g.Get("/center-summary", h.GetCenterSummary)
+	g.Get("/summary", h.GetSummary)
+	return app
+}
+
+// get runs a request against app and returns the response with its body
+func get(t *testing.T, app *fiber.App, target string) (*http.Response, string) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+	if err != nil {
+		t.Fatalf("GET %s: %v", target, err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read %s: %v", target, err)
+	}
+	return resp, string(body)
+}
+
+func TestDashboardRoutes(t *testing.T) {
+	const march = "start_date=2024-03-01&end_date=2024-03-31"
+	tests := []struct {
+		name   string
+		level  params.Level
+		target string
+		status int
+		// body is the exact JSON body of a success, or the error code
+		body string
+	}{
+		{"client counts of an officer", params.LevelOfficer, "/dashboard/?account_officer=ao1&" + march, 200,
+			`[{"particulars":"Active","count":1},{"particulars":"Total Client","count":1}]`},
+		{"client counts of a unit", params.LevelUnit, "/dashboard/?unit_name=U1&" + march, 200,
+			`[{"particulars":"Active","count":1},{"particulars":"Resigned","count":1},{"particulars":"Total Client","count":2}]`},
+		{"center summary of a center", params.LevelCenter, "/dashboard/center-summary?center_name=CA&" + march, 200,
+			`[{"center_name":"CA","no_of_clients":1,"with_loans":1,"without_loans":0,"past_due":1},{"center_name":"Total Centers","no_of_clients":1,"with_loans":1,"without_loans":0,"past_due":1}]`},
+		{"capital build-up of an officer", params.LevelOfficer, "/dashboard/capital?account_officer=ao1&" + march, 200,
+			`[{"title":"Capital Build Up","total_capital":1000}]`},
+		{"capital build-up of a unit is unsupported", params.LevelUnit, "/dashboard/capital?unit_name=U1&" + march, 400, string(apperr.CodeValidation)},
+		{"missing officer", params.LevelOfficer, "/dashboard/?" + march, 400, string(apperr.CodeValidation)},
+		{"range over the maximum", params.LevelOfficer, "/dashboard/?account_officer=ao1&start_date=2023-01-01&end_date=2024-03-31", 400, string(apperr.CodeValidation)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(t, newApp(NewHandler(seed()), tt.level), tt.target)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("GET %s = %d %s, want %d", tt.target, resp.StatusCode, body, tt.status)
+			}
+			if tt.status == fiber.StatusOK {
+				if body != tt.body {
+					t.Errorf("GET %s = %s, want %s", tt.target, body, tt.body)
+				}
+				return
+			}
+			var e apperr.Response
+			if err := json.Unmarshal([]byte(body), &e); err != nil || string(e.Code) != tt.body {
+				t.Errorf("GET %s = %s, want error code %s", tt.target, body, tt.body)
+			}
+		})
+	}
+}
+
+func TestDashboardCSVDownload(t *testing.T) {
+	app := newApp(NewHandler(seed()), params.LevelOfficer)
+	resp, body := get(t, app, "/dashboard/?account_officer=ao1&start_date=2024-03-01&end_date=2024-03-31&format=csv")
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d %s", resp.StatusCode, body)
+	}
+	if cd := resp.Header.Get(fiber.HeaderContentDisposition); !strings.Contains(cd, "ao1_2024-03-01_2024-03-31.csv") {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	if lines := strings.Split(strings.TrimSpace(body), "\n"); len(lines) != 3 {
+		t.Errorf("CSV has %d lines, want a header and two rows:\n%s", len(lines), body)
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+
+	"rbi_backend/export"
+	"rbi_backend/handlers/params"
+	"rbi_backend/logging"
+	"rbi_backend/store"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// send writes body as JSON, or results as a CSV or XLSX attachment when the
+// client negotiated a spreadsheet format
+func send[T any](c *fiber.Ctx, r export.Report[T], body any, results []T) error {
+	logging.SetRows(c, len(results))
+
+	format := export.FromCtx(c)
+	if format == export.JSON {
+		return c.JSON(body)
+	}
+
+	attach(c, r.Name, format)
+	return r.WriteAll(format, c.Response().BodyWriter(), results)
+}
+
+// attach sets the download headers, naming the file after the report, the
+// officer, unit, center or branch it covers and its date range
+func attach(c *fiber.Ctx, report string, format export.Format) {
+	f := params.Filter(c)
+	name := export.Filename(format, report, f.Value, f.StartDate.Format(params.DateLayout), f.EndDate.Format(params.DateLayout))
+	export.Attach(c, name, format)
+}
+
+// streamClients writes every client as a CSV or XLSX attachment, reading rows
+// from the database cursor while the response body is sent. Errors after the
+// first byte cannot change the status, so they are logged and the body is cut short.
+// done releases the context the rows were opened with once the stream ends.
+func streamClients(c *fiber.Ctx, format export.Format, rows store.ClientRows, done context.CancelFunc) {
+	attach(c, export.Clients.Name, format)
+
+	// The stream runs after the handler returns, so capture what it needs now
+	logger := logging.FromContext(c.UserContext()).With(slog.String("report", export.Clients.Name))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer done()
+		defer rows.Close()
+
+		n, err := copyClients(w, format, rows)
+		if err != nil {
+			logger.Error("Export failed", slog.Int("rows", n), slog.Any("error", err))
+			return
+		}
+		logger.Info("Export streamed", slog.Int("rows", n))
+	})
+}
+
+// copyClients writes the client rows to w in the given format, returning how many were written
+func copyClients(w *bufio.Writer, format export.Format, rows store.ClientRows) (int, error) {
+	ew, err := export.NewWriter(format, w, export.Clients.Sheet, export.Clients.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for rows.Next() {
+		if err := ew.Write(export.Clients.Row(rows.Client())); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	if err := ew.Close(); err != nil {
+		return n, err
+	}
+	return n, w.Flush()
+}
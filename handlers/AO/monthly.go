@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"bytes"
+
+	"rbi_backend/apperr"
+	"rbi_backend/export"
+	"rbi_backend/handlers/params"
+	"rbi_backend/pdfreport"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetMonthlyReport handles the request to render an account officer's monthly performance report as a PDF,
+// combining the client counts, loan totals, weekly capital build-up and center summary for the month
+func (h *Handler) GetMonthlyReport(c *fiber.Ctx) error {
+	f := params.Filter(c)
+
+	report, err := pdfreport.Collect(c.UserContext(), h.Store, f.Value, f.StartDate, f.EndDate, h.Bucketing.WeekStart)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to get monthly report data")
+	}
+
+	// Render fully before sending so a rendering error still gets a JSON error response
+	var buf bytes.Buffer
+	if err := pdfreport.Render(&buf, report, h.Branding); err != nil {
+		return apperr.Wrap(err, "Failed to render monthly report")
+	}
+
+	export.Attach(c, export.Filename(export.PDF, "monthly-report", f.Value, f.StartDate.Format(params.MonthLayout)), export.PDF)
+	return c.Send(buf.Bytes())
+}
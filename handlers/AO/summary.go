@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"rbi_backend/apperr"
+	"rbi_backend/export"
+	"rbi_backend/handlers/params"
+	"rbi_backend/logging"
+	"rbi_backend/store"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+// Section is one metric of the summary: its data, or the error that prevented it
+type Section struct {
+	Data  any           `json:"data"`
+	Error *SectionError `json:"error,omitempty"`
+}
+
+// SectionError describes why a section failed, in the same terms as an error response
+type SectionError struct {
+	Status  int         `json:"status"`
+	Code    apperr.Code `json:"code"`
+	Message string      `json:"message"`
+}
+
+// ClientsSection is the first page of the clients report within the summary
+type ClientsSection struct {
+	Clients    []store.ActiveClientInfo `json:"clients"`
+	Total      int                      `json:"total"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+// Summary combines every AO dashboard metric in one document
+type Summary struct {
+	TotalValues          Section `json:"total_values"`
+	LoanTotals           Section `json:"loan_totals"`
+	CapitalBuildUp       Section `json:"capital_build_up"`
+	AgeGroups            Section `json:"age_groups"`
+	ProductCounts        Section `json:"product_counts"`
+	CenterSummary        Section `json:"center_summary"`
+	WeeklyClientCount    Section `json:"weekly_client_count"`
+	WeeklyCapitalBuildUp Section `json:"weekly_capital_build_up"`
+	Clients              Section `json:"clients"`
+	// Partial is set when at least one section failed
+	Partial bool `json:"partial"`
+}
+
+// GetSummary handles the request to get every AO dashboard metric at once. The queries run concurrently under
+// one deadline; a failing section carries its error while the others are still returned.
+func (h *Handler) GetSummary(c *fiber.Ctx) error {
+	if f := export.FromCtx(c); f != export.JSON {
+		return apperr.Validation("Invalid request parameters", []apperr.FieldError{
+			{Field: "format", Message: fmt.Sprintf("%q is not supported for the summary (expected json)", f)},
+		})
+	}
+
+	filter := params.Filter(c).StoreFilter()
+	query, err := params.ParseClientQuery(c)
+	if err != nil {
+		return err
+	}
+	bucketing, err := params.ParseBucketing(c, h.Bucketing)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.UserContext()
+	if h.SummaryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.SummaryTimeout)
+		defer cancel()
+	}
+
+	var summary Summary
+	var g errgroup.Group
+	run := func(dst *Section, message string, query func(ctx context.Context) (any, error)) {
+		g.Go(func() error {
+			data, err := query(ctx)
+			if err != nil {
+				// Record the failure in the section instead of cancelling its siblings
+				appErr := apperr.Wrap(err, message)
+				dst.Error = &SectionError{Status: appErr.Status, Code: appErr.Code, Message: appErr.Message}
+				logging.FromContext(ctx).Warn("Summary section failed", slog.String("code", string(appErr.Code)), slog.String("error", appErr.Error()))
+				return appErr
+			}
+			dst.Data = data
+			return nil
+		})
+	}
+
+	run(&summary.TotalValues, "Failed to get total values", func(ctx context.Context) (any, error) {
+		return h.Store.CountsByStatus(ctx, filter)
+	})
+	run(&summary.LoanTotals, "Failed to get loan account totals", func(ctx context.Context) (any, error) {
+		return h.Store.LoanTotalsByBillType(ctx, filter)
+	})
+	run(&summary.CapitalBuildUp, "Failed to get capital build-up total", func(ctx context.Context) (any, error) {
+		return h.Store.CapitalBuildUp(ctx, filter)
+	})
+	run(&summary.AgeGroups, "Failed to get age group counts", func(ctx context.Context) (any, error) {
+		return h.Store.AgeGroups(ctx, filter)
+	})
+	run(&summary.ProductCounts, "Failed to get product counts", func(ctx context.Context) (any, error) {
+		return h.Store.ProductCounts(ctx, filter)
+	})
+	run(&summary.CenterSummary, "Failed to get center summary", func(ctx context.Context) (any, error) {
+		return h.Store.CenterSummary(ctx, filter)
+	})
+	run(&summary.WeeklyClientCount, "Failed to get weekly customer count", func(ctx context.Context) (any, error) {
+		return h.Store.WeeklyCounts(ctx, filter, bucketing)
+	})
+	run(&summary.WeeklyCapitalBuildUp, "Failed to get weekly capital build-up total", func(ctx context.Context) (any, error) {
+		return h.Store.WeeklyCapital(ctx, filter, bucketing)
+	})
+	run(&summary.Clients, "Failed to get active clients", func(ctx context.Context) (any, error) {
+		page, err := h.Store.ListClients(ctx, filter, query)
+		if err != nil {
+			return nil, err
+		}
+		if page.Clients == nil {
+			page.Clients = []store.ActiveClientInfo{}
+		}
+		return ClientsSection{Clients: page.Clients, Total: page.Total, NextCursor: page.NextCursor}, nil
+	})
+
+	// Each goroutine returns its section's error only so Wait reports whether any failed
+	if err := g.Wait(); err != nil {
+		if summary.failed() == summarySections {
+			return err
+		}
+		summary.Partial = true
+		// Partial documents must not be served from the response cache
+		c.Set(fiber.HeaderCacheControl, "no-store")
+	}
+
+	logging.SetRows(c, summarySections-summary.failed())
+	return c.JSON(summary)
+}
+
+// summarySections is the number of sections in a Summary
+const summarySections = 9
+
+// failed counts the sections that carry an error
+func (s *Summary) failed() int {
+	n := 0
+	for _, sec := range []Section{s.TotalValues, s.LoanTotals, s.CapitalBuildUp, s.AgeGroups, s.ProductCounts, s.CenterSummary, s.WeeklyClientCount, s.WeeklyCapitalBuildUp, s.Clients} {
+		if sec.Error != nil {
+			n++
+		}
+	}
+	return n
+}
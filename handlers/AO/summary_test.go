@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"rbi_backend/apperr"
+	"rbi_backend/handlers/params"
+	"rbi_backend/store"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// slowCapital is a memory store whose capital build-up query runs out of time
+type slowCapital struct {
+	*store.MemoryStore
+}
+
+func (slowCapital) CapitalBuildUp(context.Context, store.Filter) ([]store.CapitalBuildUpResult, error) {
+	return nil, context.DeadlineExceeded
+}
+
+func TestSummaryPartialResult(t *testing.T) {
+	app := newApp(NewHandler(slowCapital{seed()}), params.LevelOfficer)
+	resp, body := get(t, app, "/dashboard/summary?account_officer=ao1&start_date=2024-03-01&end_date=2024-03-31")
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d %s", resp.StatusCode, body)
+	}
+	if cc := resp.Header.Get(fiber.HeaderCacheControl); cc != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cc)
+	}
+
+	var summary map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &summary); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	if string(summary["partial"]) != "true" {
+		t.Errorf("partial = %s, want true", summary["partial"])
+	}
+
+	var failed struct {
+		Data  json.RawMessage
+		Error *SectionError
+	}
+	if err := json.Unmarshal(summary["capital_build_up"], &failed); err != nil {
+		t.Fatalf("decode capital_build_up %s: %v", summary["capital_build_up"], err)
+	}
+	want := SectionError{Status: fiber.StatusGatewayTimeout, Code: apperr.CodeTimeout, Message: "Failed to get capital build-up total"}
+	if string(failed.Data) != "null" || failed.Error == nil || *failed.Error != want {
+		t.Errorf("capital_build_up = %s, want null data and error %+v", summary["capital_build_up"], want)
+	}
+
+	for _, name := range []string{"total_values", "loan_totals", "age_groups", "product_counts", "center_summary", "weekly_client_count", "weekly_capital_build_up", "clients"} {
+		var section map[string]json.RawMessage
+		if err := json.Unmarshal(summary[name], &section); err != nil {
+			t.Errorf("decode %s %s: %v", name, summary[name], err)
+			continue
+		}
+		if _, ok := section["error"]; ok || string(section["data"]) == "null" || section["data"] == nil {
+			t.Errorf("%s = %s, want data without an error", name, summary[name])
+		}
+	}
+}
+
+func TestSummaryComplete(t *testing.T) {
+	resp, body := get(t, newApp(NewHandler(seed()), params.LevelOfficer), "/dashboard/summary?account_officer=ao1&start_date=2024-03-01&end_date=2024-03-31")
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d %s", resp.StatusCode, body)
+	}
+	if cc := resp.Header.Get(fiber.HeaderCacheControl); cc == "no-store" {
+		t.Errorf("complete summary sent Cache-Control %q", cc)
+	}
+	var summary Summary
+	if err := json.Unmarshal([]byte(body), &summary); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	if summary.Partial || summary.failed() != 0 {
+		t.Errorf("summary = %s, want every section", body)
+	}
+}
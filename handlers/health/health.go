@@ -0,0 +1,190 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	database "rbi_backend/db"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkTimeout bounds each dependency check so a hung dependency cannot stall a probe
+const checkTimeout = 3 * time.Second
+
+// Check probes one dependency. Critical checks decide readiness; the others
+// only degrade the dependency report.
+type Check struct {
+	Name     string
+	Critical bool
+	// Run returns optional details to report alongside the outcome
+	Run func(ctx context.Context) (any, error)
+}
+
+// Result is the outcome of one Check
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Details   any     `json:"details,omitempty"`
+}
+
+// Report is the body of /readyz and /health/dependencies
+type Report struct {
+	// Status is up, degraded when only non-critical checks fail, or down
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Result  `json:"checks"`
+}
+
+// Report and check statuses
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// Handler serves the liveness, readiness and dependency endpoints
+type Handler struct {
+	Checks []Check
+}
+
+// NewHandler returns a Handler running the given checks
+func NewHandler(checks ...Check) *Handler {
+	return &Handler{Checks: checks}
+}
+
+// Add registers more checks
+func (h *Handler) Add(checks ...Check) {
+	h.Checks = append(h.Checks, checks...)
+}
+
+// Live handles GET /healthz: the process is up and serving requests
+func (h *Handler) Live(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": StatusUp})
+}
+
+// Ready handles GET /readyz, running the critical checks and answering 503
+// when any of them fails
+func (h *Handler) Ready(c *fiber.Ctx) error {
+	var critical []Check
+	for _, check := range h.Checks {
+		if check.Critical {
+			critical = append(critical, check)
+		}
+	}
+	return h.respond(c, run(c.UserContext(), critical))
+}
+
+// Dependencies handles GET /health/dependencies, running every check and
+// reporting each outcome with its latency
+func (h *Handler) Dependencies(c *fiber.Ctx) error {
+	return h.respond(c, run(c.UserContext(), h.Checks))
+}
+
+// respond writes the report, with 503 once a critical check is down
+func (h *Handler) respond(c *fiber.Ctx, r Report) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	status := fiber.StatusOK
+	if r.Status == StatusDown {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(r)
+}
+
+// run executes the checks concurrently and summarizes them
+func run(ctx context.Context, checks []Check) Report {
+	report := Report{Status: StatusUp, CheckedAt: time.Now().UTC(), Checks: make([]Result, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := check.Run(ctx)
+			result := Result{
+				Name:      check.Name,
+				Status:    StatusUp,
+				Critical:  check.Critical,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+				Details:   details,
+			}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+			report.Checks[i] = result
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range report.Checks {
+		switch {
+		case r.Status == StatusUp:
+		case r.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// poolStats is the part of sql.DBStats reported with the database check
+type poolStats struct {
+	MaxOpen        int     `json:"max_open"`
+	Open           int     `json:"open"`
+	InUse          int     `json:"in_use"`
+	Idle           int     `json:"idle"`
+	WaitCount      int64   `json:"wait_count"`
+	WaitDurationMS float64 `json:"wait_duration_ms"`
+}
+
+// Database checks the pool answers a ping and reports its statistics
+func Database() Check {
+	return Check{Name: "database", Critical: true, Run: func(ctx context.Context) (any, error) {
+		err := database.Ping(ctx)
+		s := database.Stats()
+		return poolStats{
+			MaxOpen:        s.MaxOpenConnections,
+			Open:           s.OpenConnections,
+			InUse:          s.InUse,
+			Idle:           s.Idle,
+			WaitCount:      s.WaitCount,
+			WaitDurationMS: float64(s.WaitDuration.Microseconds()) / 1000,
+		}, err
+	}}
+}
+
+// Table checks a table the dashboards read from exists
+func Table(name string) Check {
+	return Check{Name: "table:" + name, Critical: true, Run: func(ctx context.Context) (any, error) {
+		return nil, exists(database.TableExists(ctx, "public."+name))
+	}}
+}
+
+// Function checks a database function the dashboards call exists
+func Function(name string) Check {
+	return Check{Name: "function:" + name, Critical: true, Run: func(ctx context.Context) (any, error) {
+		return nil, exists(database.FunctionExists(ctx, name))
+	}}
+}
+
+// exists turns a missing schema object into an error
+func exists(ok bool, err error) error {
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("does not exist")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"rbi_backend/apperr"
+	"rbi_backend/comparison"
+	"rbi_backend/handlers/params"
+	"rbi_backend/logging"
+	"rbi_backend/metric"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+// Handler serves registered metrics through a single generic endpoint
+type Handler struct {
+	Registry *metric.Registry
+	DB       *gorm.DB
+	Options  params.Options
+}
+
+// NewHandler returns a Handler running metrics from reg against db
+func NewHandler(reg *metric.Registry, db *gorm.DB, opts params.Options) *Handler {
+	return &Handler{Registry: reg, DB: db, Options: opts}
+}
+
+// MetricResponse is the JSON body returned for a metric
+type MetricResponse struct {
+	Metric    string          `json:"metric"`
+	Dimension string          `json:"dimension,omitempty"`
+	Columns   []metric.Column `json:"columns"`
+	Rows      []metric.Row    `json:"rows"`
+}
+
+// ComparisonResponse is the JSON body returned for a metric compared against an earlier range
+type ComparisonResponse struct {
+	Metric    string `json:"metric"`
+	Dimension string `json:"dimension,omitempty"`
+	comparison.Report
+}
+
+// levels lists the filter levels a metric request may use, in order of precedence
+var levels = []params.Level{params.LevelOfficer, params.LevelUnit, params.LevelCenter, params.LevelBranch}
+
+// GetMetric handles the request to run the metric named in the path, filtered at
+// whichever level parameter the query string carries
+func (h *Handler) GetMetric(c *fiber.Ctx) error {
+	def, ok := h.Registry.Get(c.Params("name"))
+	if !ok {
+		return apperr.New(fiber.StatusNotFound, apperr.CodeNotFound, "Unknown metric "+c.Params("name"))
+	}
+
+	level := params.LevelOfficer
+	for _, l := range levels {
+		if c.Query(string(l)) != "" {
+			level = l
+			break
+		}
+	}
+	filter, err := params.ParseDashboardFilter(c, level, h.Options)
+	if err != nil {
+		return err
+	}
+
+	values, problems := def.ParseParams(func(name string) string { return c.Query(name) })
+	if len(problems) > 0 {
+		fields := make([]apperr.FieldError, 0, len(problems))
+		for _, p := range def.Params {
+			if msg, ok := problems[p.Name]; ok {
+				fields = append(fields, apperr.FieldError{Field: p.Name, Message: msg})
+			}
+		}
+		return apperr.Validation("Invalid request parameters", fields)
+	}
+	compare, err := params.ParseComparison(c)
+	if err != nil {
+		return err
+	}
+	if compare != "" {
+		return h.sendComparison(c, def, filter, values, compare)
+	}
+
+	rows, err := metric.Run(c.UserContext(), h.DB, def, filter.StoreFilter(), values)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to get metric "+def.Name)
+	}
+
+	logging.SetRows(c, len(rows))
+	return c.JSON(MetricResponse{
+		Metric:    def.Name,
+		Dimension: def.Dimension,
+		Columns:   def.Columns,
+		Rows:      rows,
+	})
+}
+
+// sendComparison runs the metric over the requested range and the range it is
+// compared against under m concurrently, and sends how every value changed
+func (h *Handler) sendComparison(c *fiber.Ctx, def metric.Definition, filter params.DashboardFilter, values map[string]any, m comparison.Mode) error {
+	against := filter.Compared(m)
+
+	var current, previous []metric.Row
+	g, ctx := errgroup.WithContext(c.UserContext())
+	g.Go(func() (err error) {
+		current, err = metric.Run(ctx, h.DB, def, filter.StoreFilter(), values)
+		return err
+	})
+	g.Go(func() (err error) {
+		previous, err = metric.Run(ctx, h.DB, def, against.StoreFilter(), values)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return apperr.Wrap(err, "Failed to get metric "+def.Name)
+	}
+
+	rows := comparison.Compare(def.Records(current), def.Records(previous))
+	logging.SetRows(c, len(rows))
+	return c.JSON(ComparisonResponse{
+		Metric:    def.Name,
+		Dimension: def.Dimension,
+		Report: comparison.Report{
+			Compare:          m,
+			Period:           filter.Period(),
+			ComparisonPeriod: against.Period(),
+			Rows:             rows,
+		},
+	})
+}
@@ -0,0 +1,53 @@
+package params
+
+import (
+	"fmt"
+	"strings"
+
+	"rbi_backend/apperr"
+	"rbi_backend/timeseries"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ParseBucketing reads the granularity and week_start parameters of the trend
+// reports, falling back to def, and checks that the date range parsed by
+// RequireDashboardFilter does not split into more than timeseries.MaxPeriods periods
+func ParseBucketing(c *fiber.Ctx, def timeseries.Bucketing) (timeseries.Bucketing, error) {
+	b := def
+	var fields []apperr.FieldError
+	add := func(field, format string, args ...any) {
+		fields = append(fields, apperr.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if v := strings.TrimSpace(c.Query("granularity")); v != "" {
+		g, ok := timeseries.ParseGranularity(v)
+		if !ok {
+			names := make([]string, len(timeseries.Granularities))
+			for i, g := range timeseries.Granularities {
+				names[i] = string(g)
+			}
+			add("granularity", "%q is not supported (expected %s)", v, strings.Join(names, ", "))
+		}
+		b.Granularity = g
+	}
+	if v := strings.TrimSpace(c.Query("week_start")); v != "" {
+		d, ok := timeseries.ParseWeekday(v)
+		if !ok {
+			add("week_start", "%q is not a day of the week (expected monday to sunday)", v)
+		}
+		b.WeekStart = d
+	}
+
+	if len(fields) == 0 {
+		f := Filter(c)
+		if n := b.Count(f.StartDate, f.EndDate); n > timeseries.MaxPeriods {
+			add("granularity", "the date range spans more than %d %s periods; choose a coarser granularity", timeseries.MaxPeriods, b.Granularity)
+		}
+	}
+
+	if len(fields) > 0 {
+		return b, apperr.Validation("Invalid request parameters", fields)
+	}
+	return b, nil
+}
@@ -0,0 +1,83 @@
+package params
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"rbi_backend/apperr"
+	"rbi_backend/store"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Client report paging limits
+const (
+	DefaultClientLimit = 100
+	MaxClientLimit     = 1000
+)
+
+// ParseClientQuery reads the member status, search, sort and pagination
+// parameters of the client report and validates them
+func ParseClientQuery(c *fiber.Ctx) (store.ClientQuery, error) {
+	q := store.ClientQuery{
+		MemberStatus: strings.TrimSpace(c.Query("member_status", "Active")),
+		Search:       strings.TrimSpace(c.Query("search")),
+		Sort:         strings.TrimSpace(c.Query("sort", "client_name")),
+		Cursor:       strings.TrimSpace(c.Query("cursor")),
+		Limit:        DefaultClientLimit,
+	}
+	var fields []apperr.FieldError
+	add := func(field, message string) {
+		fields = append(fields, apperr.FieldError{Field: field, Message: message})
+	}
+
+	if _, ok := store.ClientSortKeys[q.Sort]; !ok {
+		keys := make([]string, 0, len(store.ClientSortKeys))
+		for key := range store.ClientSortKeys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		add("sort", "must be one of "+strings.Join(keys, ", "))
+	}
+
+	switch strings.ToLower(c.Query("order", "asc")) {
+	case "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		add("order", "must be asc or desc")
+	}
+
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxClientLimit {
+			add("limit", "must be an integer between 1 and "+strconv.Itoa(MaxClientLimit))
+		}
+		q.Limit = n
+	}
+
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			add("offset", "must be a non-negative integer")
+		}
+		q.Offset = n
+		if q.Cursor != "" {
+			add("offset", "cannot be combined with cursor")
+		}
+	}
+
+	if err := q.CheckCursor(); err != nil {
+		add("cursor", "must be the X-Next-Cursor of a previous page with the same sort and order")
+	}
+
+	if len(q.Search) > 100 {
+		add("search", "must be at most 100 characters")
+	}
+
+	if len(fields) > 0 {
+		return q, apperr.Validation("Invalid request parameters", fields)
+	}
+	return q, nil
+}
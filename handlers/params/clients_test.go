@@ -0,0 +1,93 @@
+package params
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"rbi_backend/apperr"
+	"rbi_backend/store"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parse runs ParseClientQuery over the query string and returns the query and
+// the fields it rejected
+func parse(t *testing.T, query string) (store.ClientQuery, []string) {
+	t.Helper()
+	var q store.ClientQuery
+	var fields []string
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		var err error
+		q, err = ParseClientQuery(c)
+		var appErr *apperr.Error
+		if errors.As(err, &appErr) {
+			for _, f := range appErr.Details.([]apperr.FieldError) {
+				fields = append(fields, f.Field)
+			}
+		} else if err != nil {
+			t.Errorf("ParseClientQuery(%s) = %v", query, err)
+		}
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?"+query, nil)); err != nil {
+		t.Fatal(err)
+	}
+	return q, fields
+}
+
+// nextCursor returns the cursor of a first page sorted by client name
+func nextCursor(t *testing.T) string {
+	t.Helper()
+	recognized := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := store.NewMemoryStore([]store.Customer{
+		{TID: "C1", CustomerName: "Ana", AccountOfficer: "ao1", MemberStatus: "Active", DateRecognized: recognized},
+		{TID: "C2", CustomerName: "Ben", AccountOfficer: "ao1", MemberStatus: "Active", DateRecognized: recognized},
+	}, nil)
+	page, err := s.ListClients(context.Background(), store.Officer("ao1", "2024-03-01", "2024-03-31"), store.ClientQuery{MemberStatus: "Active", Sort: "client_name", Limit: 1})
+	if err != nil || page.NextCursor == "" {
+		t.Fatalf("ListClients = %v, cursor %q", err, page.NextCursor)
+	}
+	return page.NextCursor
+}
+
+func TestParseClientQuery(t *testing.T) {
+	cursor := url.QueryEscape(nextCursor(t))
+	tests := []struct {
+		name  string
+		query string
+		// limit is the page size of an accepted query
+		limit  int
+		fields []string
+	}{
+		{"defaults", "", DefaultClientLimit, nil},
+		{"smallest limit", "limit=1", 1, nil},
+		{"largest limit", "limit=1000", MaxClientLimit, nil},
+		{"zero limit", "limit=0", 0, []string{"limit"}},
+		{"limit over the maximum", "limit=1001", 0, []string{"limit"}},
+		{"limit not a number", "limit=ten", 0, []string{"limit"}},
+		{"offset", "offset=20", DefaultClientLimit, nil},
+		{"negative offset", "offset=-1", 0, []string{"offset"}},
+		{"cursor", "cursor=" + cursor, DefaultClientLimit, nil},
+		{"offset with cursor", "offset=0&cursor=" + cursor, 0, []string{"offset"}},
+		{"cursor of another sort", "sort=cid&cursor=" + cursor, 0, []string{"cursor"}},
+		{"cursor of another order", "order=desc&cursor=" + cursor, 0, []string{"cursor"}},
+		{"unknown sort and order", "sort=balance&order=up", 0, []string{"sort", "order"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, fields := parse(t, tt.query)
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("ParseClientQuery(%s) rejected %v, want %v", tt.query, fields, tt.fields)
+			}
+			if tt.fields == nil && q.Limit != tt.limit {
+				t.Errorf("ParseClientQuery(%s).Limit = %d, want %d", tt.query, q.Limit, tt.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package params
+
+import (
+	"fmt"
+	"strings"
+
+	"rbi_backend/apperr"
+	"rbi_backend/comparison"
+	"rbi_backend/export"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ParseComparison reads the compare parameter of the aggregate reports, empty
+// when the request does not ask for a comparison. Comparisons are only
+// available as JSON.
+func ParseComparison(c *fiber.Ctx) (comparison.Mode, error) {
+	v := strings.TrimSpace(c.Query("compare"))
+	if v == "" {
+		return "", nil
+	}
+
+	var fields []apperr.FieldError
+	add := func(field, format string, args ...any) {
+		fields = append(fields, apperr.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	m, ok := comparison.ParseMode(v)
+	if !ok {
+		names := make([]string, len(comparison.Modes))
+		for i, m := range comparison.Modes {
+			names[i] = string(m)
+		}
+		add("compare", "%q is not supported (expected %s)", v, strings.Join(names, ", "))
+	}
+	if f := export.FromCtx(c); f != export.JSON {
+		add("format", "%q is not supported with compare (expected json)", f)
+	}
+
+	if len(fields) > 0 {
+		return m, apperr.Validation("Invalid request parameters", fields)
+	}
+	return m, nil
+}
+
+// Compared returns f moved onto the range its dates are compared against under m
+func (f DashboardFilter) Compared(m comparison.Mode) DashboardFilter {
+	f.StartDate, f.EndDate = m.Range(f.StartDate, f.EndDate)
+	return f
+}
+
+// Period returns the date range of f
+func (f DashboardFilter) Period() comparison.Period {
+	return comparison.Period{StartDate: f.StartDate.Format(DateLayout), EndDate: f.EndDate.Format(DateLayout)}
+}
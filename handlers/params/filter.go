@@ -0,0 +1,247 @@
+package params
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"rbi_backend/apperr"
+	"rbi_backend/logging"
+	"rbi_backend/rbac"
+	"rbi_backend/store"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DateLayout is the ISO date format accepted for start_date and end_date
+const DateLayout = "2006-01-02"
+
+// MonthLayout is the format accepted for the monthly report's month
+const MonthLayout = "2006-01"
+
+// filterKey is the c.Locals key holding the parsed DashboardFilter
+const filterKey = "dashboardFilter"
+
+// Level is the organisational level a dashboard aggregates at; its value is the
+// query parameter naming the officer, unit, center or branch
+type Level string
+
+const (
+	LevelOfficer Level = "account_officer"
+	LevelUnit    Level = "unit_name"
+	LevelCenter  Level = "center_name"
+	LevelBranch  Level = "branch_name"
+)
+
+// Options configures how dashboard filters are validated
+type Options struct {
+	// MaxRangeDays limits the date range; 0 disables the limit
+	MaxRangeDays int
+	// Hierarchy maps branches onto their units for branch dashboards
+	Hierarchy *rbac.Hierarchy
+	// MemberStatuses lists the accepted member_status values; empty accepts any
+	MemberStatuses []string
+}
+
+// DashboardFilter holds the validated query parameters shared by the dashboard routes
+type DashboardFilter struct {
+	Level Level
+	// Value is the officer, unit, center or branch requested; on the AO dashboard
+	// it may be empty to cover every officer in Scope
+	Value string
+	// Units holds the units of the requested branch
+	Units []string
+	// Scope is the caller's visible set, nil when authentication is disabled
+	Scope     *rbac.Scope
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// StoreFilter converts the filter into the form expected by the store layer
+func (f DashboardFilter) StoreFilter() store.Filter {
+	sf := store.Filter{
+		StartDate: f.StartDate.Format(DateLayout),
+		EndDate:   f.EndDate.Format(DateLayout),
+	}
+
+	switch {
+	case f.Level == LevelOfficer && f.Value != "":
+		sf.Officers = []string{f.Value}
+	case f.Scope == nil, f.Scope.All:
+		sf.AllOfficers = true
+	default:
+		sf.Officers = f.Scope.Officers
+	}
+
+	switch f.Level {
+	case LevelUnit:
+		sf.Units = []string{f.Value}
+	case LevelCenter:
+		sf.Centers = []string{f.Value}
+	case LevelBranch:
+		sf.Units = f.Units
+	}
+	return sf
+}
+
+// ParseDashboardFilter reads the level parameter, start_date and end_date from
+// the query string and validates them against the caller's scope. A
+// member_status, when given, must be one of opts.MemberStatuses.
+//
+// On the AO dashboard account_officer is required when authentication is
+// disabled; otherwise it is optional and, when given, must name an officer in
+// the caller's scope. Unit and branch dashboards require the unit or branch to
+// be in scope, and every level is limited to the officers the caller may see.
+func ParseDashboardFilter(c *fiber.Ctx, level Level, opts Options) (DashboardFilter, error) {
+	f := DashboardFilter{Level: level}
+	var fields []apperr.FieldError
+	add := func(field, format string, args ...any) {
+		fields = append(fields, apperr.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if scope, ok := rbac.FromCtx(c); ok {
+		f.Scope = &scope
+	}
+
+	f.Value = strings.TrimSpace(c.Query(string(level)))
+	if f.Value == "" && (level != LevelOfficer || f.Scope == nil) {
+		add(string(level), "is required")
+	}
+	if level == LevelBranch && f.Value != "" {
+		units, ok := opts.Hierarchy.BranchUnits(f.Value)
+		if !ok {
+			add(string(level), "unknown branch %q", f.Value)
+		}
+		f.Units = units
+	}
+
+	f.StartDate = parseDate(add, "start_date", c.Query("start_date"))
+	f.EndDate = parseDate(add, "end_date", c.Query("end_date"))
+
+	if !f.StartDate.IsZero() && !f.EndDate.IsZero() {
+		if f.StartDate.After(f.EndDate) {
+			add("start_date", "must not be after end_date")
+		} else if days := int(f.EndDate.Sub(f.StartDate).Hours()/24) + 1; opts.MaxRangeDays > 0 && days > opts.MaxRangeDays {
+			add("end_date", "date range of %d days exceeds the maximum of %d days", days, opts.MaxRangeDays)
+		}
+	}
+
+	if status := strings.TrimSpace(c.Query("member_status")); status != "" && len(opts.MemberStatuses) > 0 && !slices.Contains(opts.MemberStatuses, status) {
+		add("member_status", "unknown member status %q (expected one of %s)", status, strings.Join(opts.MemberStatuses, ", "))
+	}
+
+	if len(fields) > 0 {
+		return f, apperr.Validation("Invalid request parameters", fields)
+	}
+	if !f.permitted() {
+		return f, apperr.New(fiber.StatusForbidden, apperr.CodeForbidden, fmt.Sprintf("Not permitted to view %s %s", level, f.Value))
+	}
+	return f, nil
+}
+
+// permitted reports whether the caller's scope covers the requested value
+func (f DashboardFilter) permitted() bool {
+	if f.Scope == nil || f.Scope.All || f.Value == "" {
+		return true
+	}
+	switch f.Level {
+	case LevelOfficer:
+		return f.Scope.CanViewOfficer(f.Value)
+	case LevelUnit:
+		return slices.Contains(f.Scope.Units, f.Value)
+	case LevelBranch:
+		return slices.Contains(f.Scope.Branches, f.Value)
+	}
+	// Centers are not part of the hierarchy; the officer restriction applies
+	return true
+}
+
+// parseDate parses an ISO date, recording a field error when it is missing or malformed
+func parseDate(add func(field, format string, args ...any), field, value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		add(field, "is required")
+		return time.Time{}
+	}
+
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		add(field, "%q is not a valid date (expected YYYY-MM-DD)", value)
+		return time.Time{}
+	}
+	return t
+}
+
+// ParseMonthlyFilter reads account_officer and month (YYYY-MM) for the monthly
+// report; the date range covers the whole calendar month and the officer must
+// be in the caller's scope
+func ParseMonthlyFilter(c *fiber.Ctx) (DashboardFilter, error) {
+	f := DashboardFilter{Level: LevelOfficer}
+	var fields []apperr.FieldError
+	add := func(field, format string, args ...any) {
+		fields = append(fields, apperr.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if scope, ok := rbac.FromCtx(c); ok {
+		f.Scope = &scope
+	}
+
+	f.Value = strings.TrimSpace(c.Query(string(LevelOfficer)))
+	if f.Value == "" {
+		add(string(LevelOfficer), "is required")
+	}
+
+	month := strings.TrimSpace(c.Query("month"))
+	if month == "" {
+		add("month", "is required")
+	} else if start, err := time.Parse(MonthLayout, month); err != nil {
+		add("month", "%q is not a valid month (expected YYYY-MM)", month)
+	} else {
+		f.StartDate, f.EndDate = start, start.AddDate(0, 1, -1)
+	}
+
+	if len(fields) > 0 {
+		return f, apperr.Validation("Invalid request parameters", fields)
+	}
+	if !f.permitted() {
+		return f, apperr.New(fiber.StatusForbidden, apperr.CodeForbidden, fmt.Sprintf("Not permitted to view %s %s", f.Level, f.Value))
+	}
+	return f, nil
+}
+
+// RequireDashboardFilter is middleware that parses the dashboard filter at the
+// given level for every route in a group, failing with a validation error
+// naming the invalid fields
+func RequireDashboardFilter(level Level, opts Options) fiber.Handler {
+	return require(func(c *fiber.Ctx) (DashboardFilter, error) {
+		return ParseDashboardFilter(c, level, opts)
+	})
+}
+
+// RequireMonthlyFilter is middleware that parses the monthly report filter
+func RequireMonthlyFilter() fiber.Handler {
+	return require(ParseMonthlyFilter)
+}
+
+// require stores the filter returned by parse for Filter to retrieve
+func require(parse func(c *fiber.Ctx) (DashboardFilter, error)) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		f, err := parse(c)
+		if err != nil {
+			return err
+		}
+
+		c.Locals(filterKey, f)
+		if f.Level == LevelOfficer {
+			logging.SetOfficer(c, f.Value)
+		}
+		return c.Next()
+	}
+}
+
+// Filter returns the DashboardFilter parsed by RequireDashboardFilter
+func Filter(c *fiber.Ctx) DashboardFilter {
+	f, _ := c.Locals(filterKey).(DashboardFilter)
+	return f
+}
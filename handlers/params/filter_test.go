@@ -0,0 +1,251 @@
+package params
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"rbi_backend/apperr"
+	"rbi_backend/auth"
+	"rbi_backend/config"
+	"rbi_backend/rbac"
+	"rbi_backend/store"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// secret signs the test tokens
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+// hierarchy has one area of two units and a second branch elsewhere
+var hierarchy = &rbac.Hierarchy{
+	Admins: []string{"admin"},
+	Areas: []rbac.Area{
+		{Name: "North", Managers: []string{"am.north"}, Branches: []rbac.Branch{
+			{Name: "Baguio", Managers: []string{"bm.baguio"}, Units: []rbac.Unit{
+				{Name: "Baguio 1", Heads: []string{"uh.baguio1"}, Officers: []string{"AO001", "AO002"}},
+				{Name: "Baguio 2", Heads: []string{"uh.baguio2"}, Officers: []string{"AO003"}},
+			}},
+		}},
+		{Name: "South", Managers: []string{"am.south"}, Branches: []rbac.Branch{
+			{Name: "Davao", Managers: []string{"bm.davao"}, Units: []rbac.Unit{
+				{Name: "Davao 1", Heads: []string{"uh.davao1"}, Officers: []string{"AO004"}},
+			}},
+		}},
+	},
+}
+
+// scopedApp authenticates bearer tokens, resolves the caller's scope and
+// answers with the store filter of the dashboard at level
+func scopedApp(t *testing.T, level Level) *fiber.App {
+	t.Helper()
+	keyFile := filepath.Join(t.TempDir(), "jwt.key")
+	if err := os.WriteFile(keyFile, secret, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	authn, err := auth.NewAuthenticator(config.AuthConfig{Enabled: true, Algorithm: "HS256", KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+	app.Get("/", authn.Middleware(), rbac.Middleware(hierarchy), RequireDashboardFilter(level, Options{Hierarchy: hierarchy}), func(c *fiber.Ctx) error {
+		return c.JSON(Filter(c).StoreFilter())
+	})
+	return app
+}
+
+// request runs GET /?query as user and returns the status and store filter
+func request(t *testing.T, app *fiber.App, user, query string) (int, store.Filter) {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   user,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(fiber.MethodGet, "/?start_date=2024-03-01&end_date=2024-03-31&"+query, nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f store.Filter
+	if resp.StatusCode == fiber.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if err := json.Unmarshal(body, &f); err != nil {
+			t.Fatalf("decode %s: %v", body, err)
+		}
+	}
+	return resp.StatusCode, f
+}
+
+func TestDashboardFilterScope(t *testing.T) {
+	// march dates f over the requested range
+	march := func(f store.Filter) store.Filter {
+		f.StartDate, f.EndDate = "2024-03-01", "2024-03-31"
+		return f
+	}
+	within := func(officers ...string) store.Filter {
+		return march(store.Filter{Officers: officers})
+	}
+	tests := []struct {
+		name   string
+		level  Level
+		user   string
+		query  string
+		status int
+		want   store.Filter
+	}{
+		{"officer names themselves", LevelOfficer, "AO001", "account_officer=AO001", 200, within("AO001")},
+		{"officer names nobody", LevelOfficer, "AO001", "", 200, within("AO001")},
+		{"officer names another officer", LevelOfficer, "AO001", "account_officer=AO002", 403, store.Filter{}},
+		{"unit head names an officer beneath them", LevelOfficer, "uh.baguio1", "account_officer=AO002", 200, within("AO002")},
+		{"unit head names an officer of another unit", LevelOfficer, "uh.baguio1", "account_officer=AO003", 403, store.Filter{}},
+		{"unit head covers their officers", LevelOfficer, "uh.baguio1", "", 200, within("AO001", "AO002", "uh.baguio1")},
+		{"unit head views their unit", LevelUnit, "uh.baguio1", "unit_name=Baguio+1", 200,
+			march(store.Filter{Officers: []string{"AO001", "AO002", "uh.baguio1"}, Units: []string{"Baguio 1"}})},
+		{"unit head views another unit", LevelUnit, "uh.baguio1", "unit_name=Baguio+2", 403, store.Filter{}},
+		{"branch manager names an officer of the branch", LevelOfficer, "bm.baguio", "account_officer=AO003", 200, within("AO003")},
+		{"branch manager names an officer of another branch", LevelOfficer, "bm.baguio", "account_officer=AO004", 403, store.Filter{}},
+		{"branch manager views their branch", LevelBranch, "bm.baguio", "branch_name=Baguio", 200,
+			march(store.Filter{Officers: []string{"AO001", "AO002", "AO003", "bm.baguio"}, Units: []string{"Baguio 1", "Baguio 2"}})},
+		{"branch manager views another branch", LevelBranch, "bm.baguio", "branch_name=Davao", 403, store.Filter{}},
+		{"area manager names an officer of another area", LevelOfficer, "am.north", "account_officer=AO004", 403, store.Filter{}},
+		{"area manager views a unit of the area", LevelUnit, "am.north", "unit_name=Baguio+2", 200,
+			march(store.Filter{Officers: []string{"AO001", "AO002", "AO003", "am.north"}, Units: []string{"Baguio 2"}})},
+		{"admin covers every officer", LevelOfficer, "admin", "", 200, march(store.Filter{AllOfficers: true})},
+		{"admin names any officer", LevelOfficer, "admin", "account_officer=AO004", 200, within("AO004")},
+		{"admin views any branch", LevelBranch, "admin", "branch_name=Davao", 200,
+			march(store.Filter{AllOfficers: true, Units: []string{"Davao 1"}})},
+		{"center limited to the caller's officers", LevelCenter, "AO001", "center_name=Burnham", 200,
+			march(store.Filter{Officers: []string{"AO001"}, Centers: []string{"Burnham"}})},
+		{"center of an admin", LevelCenter, "admin", "center_name=Burnham", 200,
+			march(store.Filter{AllOfficers: true, Centers: []string{"Burnham"}})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, got := request(t, scopedApp(t, tt.level), tt.user, tt.query)
+			if status != tt.status {
+				t.Fatalf("%s at %s with %q = %d, want %d", tt.user, tt.level, tt.query, status, tt.status)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s at %s with %q filters %+v, want %+v", tt.user, tt.level, tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCenterExcludesOtherOfficers checks a center shared by several officers
+// only yields the rows of the caller's officers
+func TestCenterExcludesOtherOfficers(t *testing.T) {
+	recognized := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	s := store.NewMemoryStore([]store.Customer{
+		{TID: "C1", AccountOfficer: "AO001", CenterName: "Burnham", MemberStatus: "Active", DateRecognized: recognized},
+		{TID: "C2", AccountOfficer: "AO004", CenterName: "Burnham", MemberStatus: "Active", DateRecognized: recognized},
+	}, nil)
+
+	tests := []struct {
+		user  string
+		total int
+	}{
+		{"AO001", 1},
+		{"AO004", 1},
+		{"AO002", 0},
+		{"admin", 2},
+	}
+	for _, tt := range tests {
+		status, f := request(t, scopedApp(t, LevelCenter), tt.user, "center_name=Burnham")
+		if status != fiber.StatusOK {
+			t.Fatalf("%s = %d", tt.user, status)
+		}
+		rows, err := s.CountsByStatus(context.Background(), f)
+		if err != nil {
+			t.Fatalf("CountsByStatus: %v", err)
+		}
+		if total := rows[len(rows)-1].Count; total != tt.total {
+			t.Errorf("%s sees %d clients of Burnham, want %d", tt.user, total, tt.total)
+		}
+	}
+}
+
+// parseFilter runs ParseDashboardFilter at level over the query string without
+// a caller scope and returns the filter and the fields it rejected
+func parseFilter(t *testing.T, level Level, opts Options, query string) (DashboardFilter, []string) {
+	t.Helper()
+	var f DashboardFilter
+	var fields []string
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		var err error
+		f, err = ParseDashboardFilter(c, level, opts)
+		var appErr *apperr.Error
+		if errors.As(err, &appErr) && appErr.Code == apperr.CodeValidation {
+			for _, fe := range appErr.Details.([]apperr.FieldError) {
+				fields = append(fields, fe.Field)
+			}
+		} else if err != nil {
+			t.Errorf("ParseDashboardFilter(%s) = %v", query, err)
+		}
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?"+query, nil)); err != nil {
+		t.Fatal(err)
+	}
+	return f, fields
+}
+
+func TestParseDashboardFilter(t *testing.T) {
+	opts := Options{MaxRangeDays: 31, Hierarchy: hierarchy, MemberStatuses: []string{"Active", "Resigned"}}
+	tests := []struct {
+		name   string
+		level  Level
+		opts   Options
+		query  string
+		fields []string
+	}{
+		{"officer and range", LevelOfficer, opts, "account_officer=AO001&start_date=2024-03-01&end_date=2024-03-31", nil},
+		{"single day", LevelOfficer, opts, "account_officer=AO001&start_date=2024-03-01&end_date=2024-03-01", nil},
+		{"start after end", LevelOfficer, opts, "account_officer=AO001&start_date=2024-03-02&end_date=2024-03-01", []string{"start_date"}},
+		{"malformed dates", LevelOfficer, opts, "account_officer=AO001&start_date=2024-13-45&end_date=03/31/2024", []string{"start_date", "end_date"}},
+		{"missing dates", LevelOfficer, opts, "account_officer=AO001", []string{"start_date", "end_date"}},
+		{"range at the maximum", LevelOfficer, opts, "account_officer=AO001&start_date=2024-03-01&end_date=2024-03-31", nil},
+		{"range over the maximum", LevelOfficer, opts, "account_officer=AO001&start_date=2024-03-01&end_date=2024-04-01", []string{"end_date"}},
+		{"range without a maximum", LevelOfficer, Options{}, "account_officer=AO001&start_date=2020-01-01&end_date=2024-12-31", nil},
+		{"officer required without a scope", LevelOfficer, opts, "start_date=2024-03-01&end_date=2024-03-31", []string{"account_officer"}},
+		{"unit required", LevelUnit, opts, "account_officer=AO001&start_date=2024-03-01&end_date=2024-03-31", []string{"unit_name"}},
+		{"center required", LevelCenter, opts, "start_date=2024-03-01&end_date=2024-03-31", []string{"center_name"}},
+		{"branch required", LevelBranch, opts, "start_date=2024-03-01&end_date=2024-03-31", []string{"branch_name"}},
+		{"blank officer", LevelOfficer, opts, "account_officer=+&start_date=2024-03-01&end_date=2024-03-31", []string{"account_officer"}},
+		{"known branch", LevelBranch, opts, "branch_name=Baguio&start_date=2024-03-01&end_date=2024-03-31", nil},
+		{"unknown branch", LevelBranch, opts, "branch_name=Manila&start_date=2024-03-01&end_date=2024-03-31", []string{"branch_name"}},
+		{"known member status", LevelOfficer, opts, "account_officer=AO001&start_date=2024-03-01&end_date=2024-03-31&member_status=Resigned", nil},
+		{"unknown member status", LevelOfficer, opts, "account_officer=AO001&start_date=2024-03-01&end_date=2024-03-31&member_status=Retired", []string{"member_status"}},
+		{"any member status without a list", LevelOfficer, Options{}, "account_officer=AO001&start_date=2024-03-01&end_date=2024-03-31&member_status=Retired", nil},
+		{"every field at once", LevelUnit, opts, "start_date=2024-04-01&end_date=2024-03-01&member_status=active", []string{"unit_name", "start_date", "member_status"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, fields := parseFilter(t, tt.level, tt.opts, tt.query)
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("ParseDashboardFilter(%s, %s) rejected %v, want %v", tt.level, tt.query, fields, tt.fields)
+			}
+		})
+	}
+
+	f, _ := parseFilter(t, LevelBranch, opts, "branch_name=Baguio&start_date=2024-03-01&end_date=2024-03-31")
+	want := DashboardFilter{Level: LevelBranch, Value: "Baguio", Units: []string{"Baguio 1", "Baguio 2"}, StartDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("ParseDashboardFilter(branch_name=Baguio) = %+v, want %+v", f, want)
+	}
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// c.Locals keys holding fields for the request log line
+const (
+	rowsKey    = "logRows"
+	officerKey = "logOfficer"
+)
+
+// SetOfficer records the account officer the request was resolved to
+func SetOfficer(c *fiber.Ctx, officer string) {
+	c.Locals(officerKey, officer)
+}
+
+// SetRows records how many rows the handler returned, for the request log line
+func SetRows(c *fiber.Ctx, n int) {
+	c.Locals(rowsKey, n)
+}
+
+// Rows returns the row count recorded with SetRows, if any
+func Rows(c *fiber.Ctx) (int, bool) {
+	n, ok := c.Locals(rowsKey).(int)
+	return n, ok
+}
+
+// Middleware attaches a request-scoped logger, tagged with the trace ID when the
+// request is traced, to the user context and writes one log line per request
+// with its route, officer, status, latency and row count.
+// It must run after the requestid middleware and must be registered before any
+// route so the error handler has already rendered the response when it logs.
+func Middleware(base *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID, _ := c.Locals("requestid").(string)
+		logger := base.With(
+			slog.String("request_id", requestID),
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+		)
+		if sc := trace.SpanContextFromContext(c.UserContext()); sc.IsValid() {
+			logger = logger.With(slog.String("trace_id", sc.TraceID().String()))
+		}
+		c.SetUserContext(WithLogger(c.UserContext(), logger))
+
+		err := c.Next()
+		if err != nil {
+			// Render the error now so the logged status matches the response
+			if herr := c.App().ErrorHandler(c, err); herr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		attrs := []any{
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		}
+		if officer, _ := c.Locals(officerKey).(string); officer != "" {
+			attrs = append(attrs, slog.String("account_officer", officer))
+		}
+		if rows, ok := c.Locals(rowsKey).(int); ok {
+			attrs = append(attrs, slog.Int("rows", rows))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		logger.Log(c.UserContext(), level, "request", attrs...)
+
+		return nil
+	}
+}
@@ -1,42 +1,266 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"rbi_backend/apperr"
+	"rbi_backend/auth"
+	"rbi_backend/cache"
+	"rbi_backend/config"
 	database "rbi_backend/db"
+	"rbi_backend/deadline"
 	handlers "rbi_backend/handlers/AO"
+	"rbi_backend/handlers/health"
+	"rbi_backend/handlers/metrics"
+	"rbi_backend/handlers/params"
+	"rbi_backend/logging"
+	"rbi_backend/metric"
+	"rbi_backend/monitoring"
+	"rbi_backend/openapi"
+	"rbi_backend/pdfreport"
+	"rbi_backend/rbac"
+	"rbi_backend/routes"
+	"rbi_backend/scheduler"
+	"rbi_backend/store"
+	"rbi_backend/tracing"
+	"rbi_backend/versioning"
+	"strings"
+	"syscall"
+	_ "time/tzdata" // lets CRON_TZ schedules resolve zones on hosts without zoneinfo
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
 func main() {
-	app := fiber.New()
+	// Load configuration from the environment and optional config file
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Route all logging through structured JSON at the configured level
+	logger := logging.Setup(cfg.Log.Level)
+
+	// SIGINT or SIGTERM aborts a startup still waiting on dependencies, or
+	// starts the graceful shutdown once serving
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Export spans when tracing is enabled; otherwise the spans below are no-ops
+	flushTraces := func(context.Context) error { return nil }
+	if cfg.Tracing.Enabled {
+		if flushTraces, err = tracing.Setup(ctx, cfg.Tracing); err != nil {
+			log.Fatalf("Could not set up tracing: %v", err)
+		}
+		logger.Info("Tracing enabled", "exporter", cfg.Tracing.Exporter, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+
+	app := fiber.New(fiber.Config{
+		AppName:               "rbi_backend",
+		ErrorHandler:          apperr.Handler,
+		DisableStartupMessage: true,
+	})
+
+	// Tag every request with an ID, reusing X-Request-ID when the caller sends one
+	app.Use(requestid.New())
+
+	// Trace every request, continuing the frontend's trace when it sends traceparent
+	app.Use(tracing.Middleware())
+
+	// Count and time every request per route for Prometheus
+	app.Use(monitoring.Middleware())
 
-	// Enable CORS for all routes
+	// Log every request with its route, officer, status, latency and row count
+	app.Use(logging.Middleware(logger))
+
+	// Enable CORS for the configured origins
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE",
+		AllowOrigins:  strings.Join(cfg.Server.CORSOrigins, ","),
+		AllowMethods:  "GET,POST,PUT,DELETE",
+		AllowHeaders:  "Origin,Content-Type,Accept,Authorization,traceparent,tracestate,baggage",
+		ExposeHeaders: "X-Request-ID,X-Total-Count,X-Next-Cursor,Content-Disposition,Deprecation,Sunset,Link",
 	}))
 
-	// Connect to the database
-	if err := database.Connect(); err != nil {
-		log.Fatalf("Could not connect to the database: %v", err)
+	// Liveness, readiness and dependency probes and the Prometheus scrape
+	// endpoint, served without authentication
+	healthHandler := health.NewHandler()
+	routes.Operations(app, healthHandler, monitoring.Handler())
+
+	// Pick the data-access layer for the dashboard handlers
+	var dashboardStore store.AODashboardStore
+	if cfg.Database.Driver == "memory" {
+		logger.Info("Using the in-memory dashboard store")
+		dashboardStore = store.NewMemoryStore(nil, nil)
+	} else {
+		// Connect to the database
+		if err := database.Connect(ctx, cfg.Database, cfg.Log); err != nil {
+			log.Fatalf("Could not connect to the database: %v", err)
+		}
+		dashboardStore = store.NewPostgresStore(database.DB)
+		healthHandler.Add(
+			health.Database(),
+			health.Table("customer_info"),
+			health.Table("loan_acct"),
+			health.Function("get_capital_build_up"),
+		)
+	}
+	aoHandler := handlers.NewHandler(dashboardStore)
+	aoHandler.Branding = pdfreport.Branding{Organization: cfg.Report.Organization, LogoFile: cfg.Report.LogoFile}
+	aoHandler.SummaryTimeout = cfg.Dashboard.SummaryTimeout
+	aoHandler.Bucketing.WeekStart = cfg.Dashboard.Weekday()
+
+	// Load the role hierarchy used for scoping and branch dashboards
+	hierarchy := &rbac.Hierarchy{}
+	if cfg.Auth.HierarchyFile != "" {
+		if hierarchy, err = rbac.LoadHierarchy(cfg.Auth.HierarchyFile); err != nil {
+			log.Fatalf("Could not load the role hierarchy: %v", err)
+		}
+	}
+
+	// Dashboard routes require a bearer token and are limited to the caller's scope
+	var authMiddleware []fiber.Handler
+	if cfg.Auth.Enabled {
+		authenticator, err := auth.NewAuthenticator(cfg.Auth)
+		if err != nil {
+			log.Fatalf("Could not set up authentication: %v", err)
+		}
+		authMiddleware = append(authMiddleware, authenticator.Middleware(), rbac.Middleware(hierarchy))
+	} else {
+		logger.Warn("Authentication is disabled; dashboards are not scoped to the caller")
+	}
+	filterOpts := params.Options{MaxRangeDays: cfg.Dashboard.MaxRangeDays, Hierarchy: hierarchy, MemberStatuses: cfg.Dashboard.MemberStatuses}
+
+	// Cache dashboard responses per report and normalized filter
+	responseCache := cache.New(nil, 0, nil)
+	var redisStore *cache.Redis
+	if cfg.Cache.Enabled {
+		var cacheStore cache.Store = cache.NewLRU(cfg.Cache.MaxEntries)
+		if cfg.Cache.Backend == "redis" {
+			if redisStore, err = cache.NewRedis(ctx, cfg.Cache.Redis); err != nil {
+				log.Fatalf("Could not connect to the cache: %v", err)
+			}
+			cacheStore = redisStore
+			// The dashboards keep working without the cache, so it only degrades the report
+			healthHandler.Add(health.Check{Name: "cache", Run: func(ctx context.Context) (any, error) {
+				return nil, redisStore.Ping(ctx)
+			}})
+		}
+		responseCache = cache.New(cacheStore, cfg.Cache.DefaultTTL, cfg.Cache.TTLs)
+
+		// Only administrators may clear the cache, so the routes are left out
+		// when authentication is off and nobody can be identified as one
+		if cfg.Auth.Enabled {
+			invalidate := append(append([]fiber.Handler{}, authMiddleware...), rbac.RequireRole(rbac.RoleAdmin), responseCache.Invalidate)
+			routes.CacheInvalidation(app, invalidate...)
+		}
+		logger.Info("Response cache enabled", "backend", cfg.Cache.Backend)
 	}
 
-	// Group for dashboard routes
-	dashboardRoutes := app.Group("/AO-dashboard")
-	dashboardRoutes.Get("/", handlers.GetTotalCountsClient)
-	dashboardRoutes.Get("/Total-loans", handlers.GetLoanAccountTotals)
-	dashboardRoutes.Get("/age-group", handlers.GetAgeGroupCounts)
-	dashboardRoutes.Get("/capital", handlers.GetCapitalBuildUp)
-	dashboardRoutes.Get("/products-count", handlers.GetProductCounts)
-	dashboardRoutes.Get("/center-summary", handlers.GetCenterSummary)
-	dashboardRoutes.Get("/weekly-client-count", handlers.GetWeeklyCustomerCount)
-	dashboardRoutes.Get("/weekly-capital-build", handlers.GetWeeklyCapitalBuildUp)
-	dashboardRoutes.Get("/clients-report", handlers.GetClients)
+	// Bound the queries of each report
+	queryTimeouts := deadline.New(cfg.Database.QueryTimeout, cfg.Database.QueryTimeouts)
+
+	// Built-in and file-declared metrics for the generic metric endpoint
+	registry := metric.NewRegistry()
+	for _, def := range metric.Builtins() {
+		if err := registry.Register(def); err != nil {
+			log.Fatalf("Invalid built-in metric: %v", err)
+		}
+	}
+	if cfg.Dashboard.MetricsFile != "" {
+		if err := registry.LoadFile(cfg.Dashboard.MetricsFile); err != nil {
+			log.Fatalf("Could not load metrics: %v", err)
+		}
+	}
+
+	api := routes.API{
+		Dashboards: aoHandler,
+		Filter:     filterOpts,
+		Auth:       authMiddleware,
+		// Reports are served through the response cache and under their query
+		// timeout, with their queries labelled for Prometheus
+		Report: func(name string, h fiber.Handler) []fiber.Handler {
+			return []fiber.Handler{responseCache.For(name), queryTimeouts.For(name), monitoring.For(name), h}
+		},
+		Monthly: func(h fiber.Handler) []fiber.Handler {
+			return []fiber.Handler{queryTimeouts.For("monthly-report"), monitoring.For("monthly-report"), h}
+		},
+		Metric: func(h fiber.Handler) []fiber.Handler {
+			return []fiber.Handler{queryTimeouts.ForParam("name"), monitoring.ForParam("name"), h}
+		},
+	}
+	// The memory store cannot run the metrics' SQL
+	if database.DB != nil {
+		api.Metrics = metrics.NewHandler(registry, database.DB, filterOpts)
+	}
+
+	// Serve the API under /api/v1, and the original paths as deprecated aliases
+	// until their sunset
+	api.Mount(app, versioning.V1, false)
+	if cfg.API.LegacyRoutes {
+		api.Mount(app, "", true, versioning.Deprecated(cfg.API.LegacyDates()))
+	}
+
+	// Scheduled report jobs, recorded in the report_job_runs history table
+	var sched *scheduler.Scheduler
+	if cfg.Schedule.Enabled {
+		var history scheduler.History = scheduler.NewMemoryHistory()
+		if database.DB != nil {
+			if history, err = scheduler.NewGormHistory(database.DB); err != nil {
+				log.Fatalf("Could not prepare the job history table: %v", err)
+			}
+		}
+		if sched, err = scheduler.New(cfg.Schedule, dashboardStore, history, aoHandler.Branding, cfg.Dashboard.Weekday(), logger); err != nil {
+			log.Fatalf("Could not schedule report jobs: %v", err)
+		}
+		sched.Start()
+		logger.Info("Report scheduler started", "jobs", len(cfg.Schedule.Jobs))
+	}
+
+	// Describe the API as OpenAPI, refusing to start when the document and the
+	// registered routes have drifted apart
+	docOpts := openapi.Options{Auth: cfg.Auth.Enabled, Cache: cfg.Cache.Enabled, Legacy: cfg.API.LegacyRoutes, WeekStart: cfg.Dashboard.Weekday()}
+	if api.Metrics != nil {
+		docOpts.Metrics = registry.List()
+	}
+	apiDoc, err := openapi.Build(docOpts)
+	if err != nil {
+		log.Fatalf("Could not build the OpenAPI document: %v", err)
+	}
+	if err := openapi.Verify(apiDoc, app.GetRoutes(true)); err != nil {
+		log.Fatalf("The OpenAPI document does not match the routes: %v", err)
+	}
+	specHandler, err := openapi.Handler(apiDoc)
+	if err != nil {
+		log.Fatalf("Could not serve the OpenAPI document: %v", err)
+	}
+	app.Get("/openapi.json", specHandler)
+	app.Get("/docs", openapi.Docs(apiDoc.Info.Title, "/openapi.json"))
 
 	// Start the server
-	if err := app.Listen(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	listenErr := make(chan error, 1)
+	go func() {
+		logger.Info("Starting server", "addr", cfg.Server.Addr)
+		listenErr <- app.Listen(cfg.Server.Addr)
+	}()
+
+	code := exitOK
+	select {
+	case err := <-listenErr:
+		logger.Error("Server stopped unexpectedly", "error", err)
+		code = exitServerError
+	case <-ctx.Done():
+		// Restore the default handling so a second signal kills the process at once
+		stop()
+		logger.Info("Shutting down", "timeout", cfg.Server.ShutdownTimeout.String())
+	}
+
+	if !shutdown(app, sched, redisStore, flushTraces, cfg.Server.ShutdownTimeout, logger) && code == exitOK {
+		code = exitShutdownIncomplete
 	}
+	logger.Info("Stopped", "exit_code", code)
+	os.Exit(code)
 }
@@ -0,0 +1,78 @@
+package metric
+
+import "rbi_backend/store"
+
+// Builtins returns the metrics shipped with the backend; more can be declared in
+// the metrics file without code changes
+func Builtins() []Definition {
+	return []Definition{
+		{
+			Name:        "clients_by_status",
+			Description: "Client count per member status",
+			Source:      store.SourceCustomers,
+			Dimension:   "member_status",
+			SQL: `
+				SELECT ci.member_status AS particulars, COUNT(ci.t_id) AS count
+				FROM public.customer_info ci
+				WHERE {{where}}
+				GROUP BY ci.member_status
+				ORDER BY ci.member_status`,
+			Columns: []Column{{Name: "particulars", Type: TypeString}, {Name: "count", Type: TypeInt}},
+		},
+		{
+			Name:        "clients_by_unit",
+			Description: "Client count per unit, optionally for one member status",
+			Source:      store.SourceCustomers,
+			Dimension:   "unit_name",
+			SQL: `
+				SELECT ci.unit_name AS unit_name, COUNT(ci.t_id) AS count
+				FROM public.customer_info ci
+				WHERE {{where}}
+					AND ({{member_status}}::text IS NULL OR ci.member_status = {{member_status}})
+				GROUP BY ci.unit_name
+				ORDER BY ci.unit_name`,
+			Params:  []Param{{Name: "member_status", Type: TypeString}},
+			Columns: []Column{{Name: "unit_name", Type: TypeString}, {Name: "count", Type: TypeInt}},
+		},
+		{
+			Name:        "loans_by_bill_type",
+			Description: "Loan count and amount per bill type",
+			Source:      store.SourceLoans,
+			Dimension:   "bill_type",
+			SQL: `
+				SELECT la.bill_type AS particulars, COUNT(la.bill_type) AS count, SUM(la.online_actual_bal::numeric) * -1 AS amount
+				FROM public.loan_acct la
+				WHERE {{where}}
+				GROUP BY la.bill_type
+				ORDER BY la.bill_type`,
+			Columns: []Column{{Name: "particulars", Type: TypeString}, {Name: "count", Type: TypeInt}, {Name: "amount", Type: TypeFloat}},
+		},
+		{
+			Name:        "loans_by_product",
+			Description: "Loan count per product",
+			Source:      store.SourceLoans,
+			Dimension:   "account_title_1",
+			SQL: `
+				SELECT la.account_title_1 AS product_name, COUNT(la.account_title_1) AS count
+				FROM public.loan_acct la
+				WHERE {{where}}
+				GROUP BY la.account_title_1
+				ORDER BY la.account_title_1`,
+			Columns: []Column{{Name: "product_name", Type: TypeString}, {Name: "count", Type: TypeInt}},
+		},
+		{
+			Name:        "past_due_by_center",
+			Description: "Clients with a past-due loan per center",
+			Source:      store.SourceCustomers,
+			Dimension:   "center_name",
+			SQL: `
+				SELECT ci.center_name AS center_name, COUNT(DISTINCT ci.t_id) AS past_due
+				FROM public.customer_info ci
+				JOIN public.loan_acct la ON ci.t_id = la.customer AND la.bill_status = 'DUE'
+				WHERE {{where}}
+				GROUP BY ci.center_name
+				ORDER BY ci.center_name`,
+			Columns: []Column{{Name: "center_name", Type: TypeString}, {Name: "past_due", Type: TypeInt}},
+		},
+	}
+}
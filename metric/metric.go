@@ -0,0 +1,302 @@
+package metric
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rbi_backend/store"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ColumnType is the JSON type a result column is converted to
+type ColumnType string
+
+const (
+	TypeString ColumnType = "string"
+	TypeInt    ColumnType = "int"
+	TypeFloat  ColumnType = "float"
+)
+
+// Column describes one column of a metric's result set; Name must match the
+// column alias in the SQL and is used as the JSON key
+type Column struct {
+	Name string     `yaml:"name" json:"name"`
+	Type ColumnType `yaml:"type" json:"type"`
+}
+
+// Param is an extra query parameter a metric accepts besides the dashboard filter
+type Param struct {
+	Name     string     `yaml:"name" json:"name"`
+	Type     ColumnType `yaml:"type" json:"type"`
+	Default  string     `yaml:"default" json:"default,omitempty"`
+	Required bool       `yaml:"required" json:"required,omitempty"`
+	Allowed  []string   `yaml:"allowed" json:"allowed,omitempty"`
+}
+
+// Definition declares a metric once: the SQL it runs, the parameters it takes,
+// the columns it returns and the dimension its rows are grouped by.
+//
+// The SQL may reference {{where}}, which expands to the dashboard filter
+// condition for Source, and {{name}} for each declared Param; both are always
+// sent as bound arguments, never interpolated.
+type Definition struct {
+	Name        string       `yaml:"name" json:"name"`
+	Description string       `yaml:"description" json:"description"`
+	Source      store.Source `yaml:"source" json:"source"`
+	Dimension   string       `yaml:"dimension" json:"dimension,omitempty"`
+	SQL         string       `yaml:"sql" json:"-"`
+	Params      []Param      `yaml:"params" json:"params,omitempty"`
+	Columns     []Column     `yaml:"columns" json:"columns"`
+}
+
+// placeholder matches {{where}} and {{param}} references in a SQL template
+var placeholder = regexp.MustCompile(`\{\{\s*([a-z_][a-z0-9_]*)\s*\}\}`)
+
+// namePattern restricts metric and parameter names to URL and SQL safe identifiers
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Validate checks that the definition is complete and self-consistent
+func (d Definition) Validate() error {
+	var errs []error
+
+	if !namePattern.MatchString(d.Name) {
+		errs = append(errs, fmt.Errorf("name %q must be lower_snake_case", d.Name))
+	}
+	if d.Source != store.SourceCustomers && d.Source != store.SourceLoans {
+		errs = append(errs, fmt.Errorf("source %q must be %q or %q", d.Source, store.SourceCustomers, store.SourceLoans))
+	}
+	if len(d.Columns) == 0 {
+		errs = append(errs, errors.New("at least one column is required"))
+	}
+	for _, col := range d.Columns {
+		if !validType(col.Type) {
+			errs = append(errs, fmt.Errorf("column %q has unknown type %q", col.Name, col.Type))
+		}
+	}
+
+	params := map[string]bool{}
+	for _, p := range d.Params {
+		if !namePattern.MatchString(p.Name) || p.Name == "where" {
+			errs = append(errs, fmt.Errorf("param name %q is not allowed", p.Name))
+		}
+		if !validType(p.Type) {
+			errs = append(errs, fmt.Errorf("param %q has unknown type %q", p.Name, p.Type))
+		}
+		params[p.Name] = true
+	}
+
+	usesWhere := false
+	for _, m := range placeholder.FindAllStringSubmatch(d.SQL, -1) {
+		switch {
+		case m[1] == "where":
+			usesWhere = true
+		case !params[m[1]]:
+			errs = append(errs, fmt.Errorf("sql references undeclared param {{%s}}", m[1]))
+		}
+	}
+	if !usesWhere {
+		errs = append(errs, errors.New("sql must reference {{where}} so the dashboard filter is applied"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("metric %q: %w", d.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// validType reports whether t is a known column type
+func validType(t ColumnType) bool {
+	return t == TypeString || t == TypeInt || t == TypeFloat
+}
+
+// Bind expands the SQL template for a filter and validated parameter values,
+// returning the query and its positional arguments
+func (d Definition) Bind(f store.Filter, values map[string]any) (string, []any, error) {
+	cond, condArgs, err := store.Condition(d.Source, f)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var args []any
+	query := placeholder.ReplaceAllStringFunc(d.SQL, func(m string) string {
+		name := placeholder.FindStringSubmatch(m)[1]
+		if name == "where" {
+			args = append(args, condArgs...)
+			return "(" + cond + ")"
+		}
+		args = append(args, values[name])
+		return "?"
+	})
+	return query, args, nil
+}
+
+// ParseParams validates raw query values against the declared parameters,
+// applying defaults; the returned map is keyed by parameter name
+func (d Definition) ParseParams(get func(name string) string) (map[string]any, map[string]string) {
+	values := map[string]any{}
+	problems := map[string]string{}
+
+	for _, p := range d.Params {
+		raw := strings.TrimSpace(get(p.Name))
+		if raw == "" {
+			raw = p.Default
+		}
+		if raw == "" {
+			if p.Required {
+				problems[p.Name] = "is required"
+			} else {
+				values[p.Name] = nil
+			}
+			continue
+		}
+		if len(p.Allowed) > 0 && !slices.Contains(p.Allowed, raw) {
+			problems[p.Name] = fmt.Sprintf("must be one of %s", strings.Join(p.Allowed, ", "))
+			continue
+		}
+
+		switch p.Type {
+		case TypeInt:
+			n, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				problems[p.Name] = fmt.Sprintf("%q is not an integer", raw)
+				continue
+			}
+			values[p.Name] = n
+		case TypeFloat:
+			n, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				problems[p.Name] = fmt.Sprintf("%q is not a number", raw)
+				continue
+			}
+			values[p.Name] = n
+		default:
+			values[p.Name] = raw
+		}
+	}
+	return values, problems
+}
+
+// Convert turns a scanned database value into the column's JSON type
+func (c Column) Convert(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	var s string
+	switch x := v.(type) {
+	case int64:
+		if c.Type == TypeFloat {
+			return float64(x), nil
+		}
+		if c.Type == TypeInt {
+			return x, nil
+		}
+		s = strconv.FormatInt(x, 10)
+	case int32:
+		return c.Convert(int64(x))
+	case float64:
+		if c.Type == TypeFloat {
+			return x, nil
+		}
+		if c.Type == TypeInt {
+			return int64(x), nil
+		}
+		s = strconv.FormatFloat(x, 'f', -1, 64)
+	case []byte:
+		s = string(x)
+	case string:
+		s = x
+	case time.Time:
+		s = x.Format("2006-01-02")
+	case fmt.Stringer:
+		s = x.String()
+	default:
+		s = fmt.Sprint(x)
+	}
+
+	switch c.Type {
+	case TypeInt:
+		return strconv.ParseInt(s, 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(s, 64)
+	}
+	return s, nil
+}
+
+// Registry holds the declared metrics by name
+type Registry struct {
+	mu   sync.RWMutex
+	defs map[string]Definition
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{defs: map[string]Definition{}}
+}
+
+// Register validates and adds a metric, rejecting duplicate names
+func (r *Registry) Register(d Definition) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.defs[d.Name]; ok {
+		return fmt.Errorf("metric %q is already registered", d.Name)
+	}
+	r.defs[d.Name] = d
+	return nil
+}
+
+// Get returns the metric with the given name
+func (r *Registry) Get(name string) (Definition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.defs[name]
+	return d, ok
+}
+
+// List returns every registered metric sorted by name
+func (r *Registry) List() []Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make([]Definition, 0, len(r.defs))
+	for _, d := range r.defs {
+		defs = append(defs, d)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// LoadFile registers every metric declared in a YAML file under a "metrics" key
+func (r *Registry) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("metric: read %s: %w", path, err)
+	}
+
+	var file struct {
+		Metrics []Definition `yaml:"metrics"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("metric: parse %s: %w", path, err)
+	}
+
+	for _, d := range file.Metrics {
+		if err := r.Register(d); err != nil {
+			return fmt.Errorf("metric: %s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+
+	"rbi_backend/comparison"
+	"rbi_backend/store"
+
+	"gorm.io/gorm"
+)
+
+// Row is one result row keyed by column name
+type Row map[string]any
+
+// Run executes a metric against the database for the filter and parameter values
+func Run(ctx context.Context, db *gorm.DB, d Definition, f store.Filter, values map[string]any) ([]Row, error) {
+	query, args, err := d.Bind(f, values)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.WithContext(ctx).Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	// Map each returned column onto its declaration; undeclared columns are dropped
+	columns := make([]*Column, len(names))
+	for i, name := range names {
+		for j := range d.Columns {
+			if d.Columns[j].Name == name {
+				columns[i] = &d.Columns[j]
+			}
+		}
+	}
+
+	results := []Row{}
+	for rows.Next() {
+		raw := make([]any, len(names))
+		ptrs := make([]any, len(names))
+		for i := range raw {
+			ptrs[i] = &raw[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := Row{}
+		for i, col := range columns {
+			if col == nil {
+				continue
+			}
+			v, err := col.Convert(raw[i])
+			if err != nil {
+				return nil, fmt.Errorf("metric %q: column %q: %w", d.Name, col.Name, err)
+			}
+			row[col.Name] = v
+		}
+		results = append(results, row)
+	}
+
+	return results, rows.Err()
+}
+
+// Records splits result rows for comparison: string columns make up the key
+// and int and float columns the values, with NULL values counting as zero
+func (d Definition) Records(rows []Row) []comparison.Record {
+	records := make([]comparison.Record, len(rows))
+	for i, row := range rows {
+		r := comparison.Record{Key: map[string]string{}, Values: map[string]float64{}}
+		for _, col := range d.Columns {
+			switch v := row[col.Name]; col.Type {
+			case TypeString:
+				r.Key[col.Name], _ = v.(string)
+			case TypeInt:
+				n, _ := v.(int64)
+				r.Values[col.Name] = float64(n)
+			case TypeFloat:
+				r.Values[col.Name], _ = v.(float64)
+			}
+		}
+		records[i] = r
+	}
+	return records
+}
@@ -0,0 +1,59 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// startKey is the statement setting holding when the statement started
+const startKey = "monitoring:start"
+
+// GormPlugin times every query, row and raw statement GORM runs and labels it
+// with the dashboard metric found in the statement's context
+type GormPlugin struct{}
+
+// Name implements gorm.Plugin
+func (GormPlugin) Name() string {
+	return "monitoring"
+}
+
+// Initialize implements gorm.Plugin, registering the timing callbacks
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Query().Before("gorm:query").Register("monitoring:before_query", before),
+		cb.Query().After("gorm:query").Register("monitoring:after_query", after),
+		cb.Row().Before("gorm:row").Register("monitoring:before_row", before),
+		cb.Row().After("gorm:row").Register("monitoring:after_row", after),
+		cb.Raw().Before("gorm:raw").Register("monitoring:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("monitoring:after_raw", after),
+	)
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func after(db *gorm.DB) {
+	v, ok := db.InstanceGet(startKey)
+	if !ok {
+		return
+	}
+	start, ok := v.(time.Time)
+	if !ok {
+		return
+	}
+
+	var ctx context.Context
+	if db.Statement != nil {
+		ctx = db.Statement.Context
+	}
+	metric := metricFrom(ctx)
+	sqlDuration.WithLabelValues(metric).Observe(time.Since(start).Seconds())
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		sqlErrors.WithLabelValues(metric).Inc()
+	}
+}
@@ -0,0 +1,173 @@
+// Package monitoring exposes the service's Prometheus metrics: HTTP traffic
+// per route, SQL timings and row counts per dashboard metric, the database
+// pool and the response cache.
+package monitoring
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"rbi_backend/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric of the service
+const namespace = "rbi"
+
+// Registry holds the service's collectors; it is separate from the global
+// default registry so only what is registered here is exposed
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "route"})
+
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests being served.",
+	})
+
+	sqlDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sql_query_duration_seconds",
+		Help:      "SQL statement latency by dashboard metric.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"metric"})
+
+	sqlErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sql_query_errors_total",
+		Help:      "Failed SQL statements by dashboard metric.",
+	}, []string{"metric"})
+
+	metricRows = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "metric_rows",
+		Help:      "Rows returned per request by dashboard metric.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	}, []string{"metric"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Response cache lookups by report and result (hit or miss).",
+	}, []string{"report", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, httpInFlight,
+		sqlDuration, sqlErrors, metricRows,
+		cacheLookups,
+	)
+}
+
+// Handler serves the registry in the Prometheus text exposition format
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
+
+// RegisterDB exposes the connection pool statistics of db as go_sql_* metrics
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Middleware counts and times every request by its route pattern, so path
+// parameters do not multiply the series. It must be registered before the
+// logging middleware, which renders errors, so the final status is recorded.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		httpInFlight.Inc()
+		defer httpInFlight.Dec()
+
+		err := c.Next()
+
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		if status == fiber.StatusNotFound && route == "/" && c.Path() != "/" {
+			// Unmatched paths fall through to the root; keep them out of real routes
+			route = "unmatched"
+		}
+		method := c.Method()
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// metricKey is the context key carrying the dashboard metric being served
+type metricKey struct{}
+
+// WithMetric returns ctx labelled with the dashboard metric its queries serve
+func WithMetric(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, metricKey{}, name)
+}
+
+// metricFrom returns the metric ctx was labelled with, or "other" for queries
+// outside a dashboard request such as health checks and report jobs
+func metricFrom(ctx context.Context) string {
+	if ctx != nil {
+		if name, ok := ctx.Value(metricKey{}).(string); ok && name != "" {
+			return name
+		}
+	}
+	return "other"
+}
+
+// handler labels the request context and observes the row count set by the handler
+func (m metricLabel) handler(c *fiber.Ctx) error {
+	name := m(c)
+	parent := c.UserContext()
+	c.SetUserContext(WithMetric(parent, name))
+	err := c.Next()
+	c.SetUserContext(parent)
+
+	if rows, ok := logging.Rows(c); ok {
+		metricRows.WithLabelValues(name).Observe(float64(rows))
+	}
+	return err
+}
+
+// metricLabel resolves the metric name of a request
+type metricLabel func(*fiber.Ctx) string
+
+// For is middleware labelling the queries of one report or metric with its
+// name and recording how many rows each request returned
+func For(name string) fiber.Handler {
+	return metricLabel(func(*fiber.Ctx) string { return name }).handler
+}
+
+// ForParam is For with the metric named by a route parameter, as in /metrics/{name}
+func ForParam(param string) fiber.Handler {
+	return metricLabel(func(c *fiber.Ctx) string { return c.Params(param) }).handler
+}
+
+// CacheLookup records a response cache hit or miss for report
+func CacheLookup(report string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(report, result).Inc()
+}
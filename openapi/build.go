@@ -0,0 +1,547 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"rbi_backend/apperr"
+	"rbi_backend/comparison"
+	"rbi_backend/export"
+	handlers "rbi_backend/handlers/AO"
+	"rbi_backend/handlers/health"
+	"rbi_backend/handlers/metrics"
+	"rbi_backend/handlers/params"
+	"rbi_backend/metric"
+	"rbi_backend/routes"
+	"rbi_backend/store"
+	"rbi_backend/timeseries"
+	"rbi_backend/versioning"
+)
+
+// Version is the version of the API described by the document
+const Version = "1.0.0"
+
+// Options describes the optional parts of the API the server was started with,
+// so the document matches the routes actually registered
+type Options struct {
+	// Auth documents the bearer token the dashboards, reports and metrics require
+	Auth bool
+	// Cache documents the cache headers, and with Auth the administrator's
+	// invalidation routes
+	Cache bool
+	// Legacy documents the unversioned aliases of the /api/v1 routes
+	Legacy bool
+	// WeekStart is the configured first day of week periods
+	WeekStart time.Weekday
+	// Metrics lists the metrics served under /metrics/{name}; the route is
+	// left out when there are none, as with the memory store
+	Metrics []metric.Definition
+}
+
+// summarySections maps each section of the summary onto the report whose body
+// its data carries
+var summarySections = map[string]reflect.Type{
+	"total_values":            routes.RowsOf(export.TotalValues),
+	"loan_totals":             routes.RowsOf(export.LoanTotals),
+	"capital_build_up":        routes.RowsOf(export.CapitalBuildUp),
+	"age_groups":              routes.ObjectOf(export.AgeGroups),
+	"product_counts":          routes.RowsOf(export.ProductCounts),
+	"center_summary":          routes.RowsOf(export.CenterSummary),
+	"weekly_client_count":     routes.RowsOf(export.WeeklyCounts),
+	"weekly_capital_build_up": routes.RowsOf(export.WeeklyCapital),
+	"clients":                 reflect.TypeFor[handlers.ClientsSection](),
+}
+
+// builder accumulates the document's paths and component schemas
+type builder struct {
+	opts    Options
+	schemas *schemas
+	paths   map[string]*PathItem
+}
+
+// Build returns the document describing every route main registers with opts
+func Build(opts Options) (*Document, error) {
+	b := &builder{opts: opts, schemas: newSchemas(), paths: map[string]*PathItem{}}
+	if err := b.summarySchema(); err != nil {
+		return nil, err
+	}
+
+	b.mount(versioning.V1, false)
+	if opts.Legacy {
+		b.mount("", true)
+	}
+	b.add("GET", routes.Prometheus, &Operation{
+		Tags:        []string{"Operations"},
+		Summary:     "Prometheus metrics",
+		OperationID: "prometheusMetrics",
+		Responses: map[string]*Response{
+			"200": {Description: "Metrics in the Prometheus text exposition format", Content: map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string"}}}},
+		},
+	})
+	b.health()
+	if opts.Cache && opts.Auth {
+		b.cache()
+	}
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "RBI dashboard API",
+			Description: "Dashboard reports aggregated per account officer, unit, center and branch. Errors share one JSON body; see the Error schema.",
+			Version:     Version,
+		},
+		Paths:      b.paths,
+		Components: Components{Schemas: b.schemas.defs},
+	}
+	if opts.Auth {
+		doc.Components.SecuritySchemes = map[string]*SecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		}
+	}
+	return doc, nil
+}
+
+// add registers op under method and path
+func (b *builder) add(method, path string, op *Operation) {
+	item := b.paths[path]
+	if item == nil {
+		item = &PathItem{}
+		b.paths[path] = item
+	}
+	switch method {
+	case "GET":
+		item.Get = op
+	case "DELETE":
+		item.Delete = op
+	}
+}
+
+// mount documents the dashboards, the monthly report and the metric endpoint
+// under root, as routes.API.Mount registers them
+func (b *builder) mount(root string, legacy bool) {
+	for _, d := range routes.Dashboards(legacy) {
+		for _, r := range d.Reports {
+			b.api(root, legacy, joinPath(d.Prefix, r.Path), b.report(d, r))
+		}
+	}
+	b.api(root, legacy, routes.Monthly, b.monthly())
+	if len(b.opts.Metrics) > 0 {
+		b.api(root, legacy, templatePath(routes.Metric), b.metric())
+	}
+}
+
+// api adds a GET operation of the API tree under root. Legacy operations are
+// marked deprecated, point at their v1 successor and document the headers
+// announcing the deprecation.
+func (b *builder) api(root string, legacy bool, path string, op *Operation) {
+	if legacy {
+		successor := versioning.Successor(path)
+		op.Tags = []string{"Legacy"}
+		op.OperationID = "legacy" + strings.ToUpper(op.OperationID[:1]) + op.OperationID[1:]
+		op.Deprecated = true
+		op.Description = strings.TrimSpace("Deprecated alias of " + successor + ".\n\n" + op.Description)
+		for _, resp := range op.Responses {
+			if resp.Headers == nil {
+				resp.Headers = map[string]*Header{}
+			}
+			resp.Headers["Deprecation"] = &Header{Description: "When the path was deprecated, as @<unix seconds>; sent once configured", Schema: &Schema{Type: "string"}}
+			resp.Headers["Sunset"] = &Header{Description: "When the path may stop responding, as an HTTP date; sent once configured", Schema: &Schema{Type: "string"}}
+			resp.Headers["Link"] = &Header{Description: "The successor-version path", Schema: &Schema{Type: "string"}}
+		}
+	}
+	b.add("GET", root+path, op)
+}
+
+// summarySchema registers the summary with the data of each section typed
+// after its report, failing when a section of handlers.Summary is not mapped
+func (b *builder) summarySchema() error {
+	summaryRef := b.schemas.of(reflect.TypeFor[handlers.Summary]())
+	summary := b.schemas.defs[strings.TrimPrefix(summaryRef.Ref, "#/components/schemas/")]
+	sectionError := b.schemas.of(reflect.TypeFor[handlers.SectionError]())
+
+	var missing []string
+	for name, prop := range summary.Properties {
+		if prop.Ref == "" || !strings.HasSuffix(prop.Ref, "/Section") {
+			continue
+		}
+		data, ok := summarySections[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		summary.Properties[name] = &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"data": nullable(b.schemas.of(data)), "error": sectionError},
+			Required:   []string{"data"},
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("summary sections without a documented body: %s", strings.Join(missing, ", "))
+	}
+	// Every section now has its own inline schema
+	delete(b.schemas.defs, "Section")
+	delete(b.schemas.types, "Section")
+	return nil
+}
+
+// report documents a dashboard report route
+func (b *builder) report(d routes.Dashboard, r routes.Report) *Operation {
+	op := &Operation{
+		Tags:        []string{d.Tag},
+		Summary:     r.Summary,
+		OperationID: operationID(d.Prefix, r.Name),
+		Parameters:  []*Parameter{b.levelParam(d.Level), dateParam("start_date", "First day of the period"), dateParam("end_date", "Last day of the period")},
+		Responses:   map[string]*Response{},
+		Security:    b.security(),
+	}
+
+	formats := []string{string(export.JSON), string(export.CSV), string(export.XLSX)}
+	if r.JSONOnly {
+		formats = formats[:1]
+	}
+	op.Parameters = append(op.Parameters, &Parameter{
+		Name:        "format",
+		In:          "query",
+		Description: "Response format; the Accept header is used when absent",
+		Schema:      &Schema{Type: "string", Enum: formats, Default: string(export.JSON)},
+	})
+	if r.Clients {
+		op.Parameters = append(op.Parameters, clientParams()...)
+	}
+	if r.Series {
+		op.Parameters = append(op.Parameters, b.seriesParams()...)
+	}
+	body := b.schemas.of(r.Body)
+	if r.Aggregate {
+		op.Parameters = append(op.Parameters, compareParam())
+		body = &Schema{OneOf: []*Schema{body, b.comparisonSchema()}}
+	}
+
+	ok := &Response{
+		Description: r.Summary,
+		Headers:     map[string]*Header{},
+		Content:     map[string]*MediaType{"application/json": {Schema: body}},
+	}
+	if !r.JSONOnly {
+		ok.Content["text/csv"] = &MediaType{Schema: &Schema{Type: "string"}}
+		ok.Content[export.MIMEXLSX] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+		ok.Headers["Content-Disposition"] = &Header{Description: "Download filename of CSV and XLSX responses", Schema: &Schema{Type: "string"}}
+	}
+	if r.Clients && !r.JSONOnly {
+		ok.Headers["X-Total-Count"] = &Header{Description: "Number of clients matching the filter", Schema: &Schema{Type: "integer"}}
+		ok.Headers["X-Next-Cursor"] = &Header{Description: "Cursor of the next page, absent on the last page", Schema: &Schema{Type: "string"}}
+	}
+	if b.opts.Cache {
+		ok.Headers["ETag"] = &Header{Description: "Entity tag of the cached body", Schema: &Schema{Type: "string"}}
+		ok.Headers["X-Cache"] = &Header{Description: "HIT when served from the response cache, otherwise MISS", Schema: &Schema{Type: "string", Enum: []string{"HIT", "MISS"}}}
+		op.Responses["304"] = &Response{Description: "The body matches the If-None-Match entity tag"}
+	}
+	op.Responses["200"] = ok
+	b.errors(op, "400", "408", "500", "503", "504")
+	return op
+}
+
+// monthly documents the monthly PDF report
+func (b *builder) monthly() *Operation {
+	op := &Operation{
+		Tags:        []string{"Reports"},
+		Summary:     "Printable monthly performance report of an account officer",
+		OperationID: "monthlyReport",
+		Parameters: []*Parameter{
+			{Name: string(params.LevelOfficer), In: "query", Required: true, Description: "Account officer to report on", Schema: &Schema{Type: "string"}},
+			{Name: "month", In: "query", Required: true, Description: "Calendar month as YYYY-MM", Schema: &Schema{Type: "string", Pattern: `^\d{4}-\d{2}$`}},
+		},
+		Responses: map[string]*Response{
+			"200": {
+				Description: "The report as a PDF document",
+				Headers:     map[string]*Header{"Content-Disposition": {Description: "Download filename", Schema: &Schema{Type: "string"}}},
+				Content:     map[string]*MediaType{export.PDF.ContentType(): {Schema: &Schema{Type: "string", Format: "binary"}}},
+			},
+		},
+		Security: b.security(),
+	}
+	b.errors(op, "400", "408", "500", "503", "504")
+	return op
+}
+
+// metric documents the generic metric endpoint and the registered metrics
+func (b *builder) metric() *Operation {
+	name := &Schema{Type: "string"}
+	var desc strings.Builder
+	desc.WriteString("Runs a metric filtered at the first level parameter present, in the order account_officer, unit_name, center_name, branch_name.")
+	for _, def := range b.opts.Metrics {
+		name.Enum = append(name.Enum, def.Name)
+		fmt.Fprintf(&desc, "\n\n**%s**: %s", def.Name, def.Description)
+		for _, p := range def.Params {
+			fmt.Fprintf(&desc, "\n- `%s` (%s", p.Name, p.Type)
+			if p.Required {
+				desc.WriteString(", required")
+			}
+			if p.Default != "" {
+				fmt.Fprintf(&desc, ", default %s", p.Default)
+			}
+			if len(p.Allowed) > 0 {
+				fmt.Fprintf(&desc, ", one of %s", strings.Join(p.Allowed, ", "))
+			}
+			desc.WriteString(")")
+		}
+	}
+
+	op := &Operation{
+		Tags:        []string{"Metrics"},
+		Summary:     "Run a declared metric",
+		Description: desc.String(),
+		OperationID: "getMetric",
+		Parameters:  []*Parameter{{Name: "name", In: "path", Required: true, Description: "Metric name", Schema: name}},
+		Responses: map[string]*Response{
+			"200": {Description: "The metric's rows", Content: map[string]*MediaType{"application/json": {Schema: &Schema{OneOf: []*Schema{
+				b.schemas.of(reflect.TypeFor[metrics.MetricResponse]()),
+				b.schemas.of(reflect.TypeFor[metrics.ComparisonResponse]()),
+			}}}}},
+		},
+		Security: b.security(),
+	}
+	for _, level := range []params.Level{params.LevelOfficer, params.LevelUnit, params.LevelCenter, params.LevelBranch} {
+		op.Parameters = append(op.Parameters, &Parameter{Name: string(level), In: "query", Description: "Filter level; one level is required", Schema: &Schema{Type: "string"}})
+	}
+	op.Parameters = append(op.Parameters, dateParam("start_date", "First day of the period"), dateParam("end_date", "Last day of the period"), compareParam())
+	b.errors(op, "400", "404", "408", "500", "501", "503", "504")
+	return op
+}
+
+// health documents the probes
+func (b *builder) health() {
+	report := &MediaType{Schema: b.schemas.of(reflect.TypeFor[health.Report]())}
+	live := &Schema{Type: "object", Properties: map[string]*Schema{"status": {Type: "string", Enum: []string{health.StatusUp}}}, Required: []string{"status"}}
+
+	b.add("GET", routes.Liveness, &Operation{
+		Tags:        []string{"Operations"},
+		Summary:     "Liveness probe",
+		OperationID: "liveness",
+		Responses:   map[string]*Response{"200": {Description: "The process is serving requests", Content: map[string]*MediaType{"application/json": {Schema: live}}}},
+	})
+	b.add("GET", routes.Readiness, &Operation{
+		Tags:        []string{"Operations"},
+		Summary:     "Readiness probe running the critical dependency checks",
+		OperationID: "readiness",
+		Responses: map[string]*Response{
+			"200": {Description: "Every critical dependency is up", Content: map[string]*MediaType{"application/json": report}},
+			"503": {Description: "A critical dependency is down", Content: map[string]*MediaType{"application/json": report}},
+		},
+	})
+	b.add("GET", routes.Dependencies, &Operation{
+		Tags:        []string{"Operations"},
+		Summary:     "Outcome and latency of every dependency check",
+		OperationID: "dependencies",
+		Responses: map[string]*Response{
+			"200": {Description: "Every critical dependency is up", Content: map[string]*MediaType{"application/json": report}},
+			"503": {Description: "A critical dependency is down", Content: map[string]*MediaType{"application/json": report}},
+		},
+	})
+}
+
+// cache documents the administrator's cache invalidation routes
+func (b *builder) cache() {
+	invalidated := &Response{
+		Description: "Number of cached responses removed",
+		Content: map[string]*MediaType{"application/json": {Schema: &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"invalidated": {Type: "integer"}},
+			Required:   []string{"invalidated"},
+		}}},
+	}
+
+	all := &Operation{
+		Tags:        []string{"Operations"},
+		Summary:     "Clear the response cache",
+		OperationID: "invalidateCache",
+		Responses:   map[string]*Response{"200": invalidated},
+		Security:    b.security(),
+	}
+	b.errors(all, "500")
+	b.add("DELETE", routes.Cache, all)
+
+	one := &Operation{
+		Tags:        []string{"Operations"},
+		Summary:     "Clear the cached responses of one report",
+		OperationID: "invalidateCachedReport",
+		Parameters:  []*Parameter{{Name: "report", In: "path", Required: true, Description: "Report name, such as center-summary", Schema: &Schema{Type: "string"}}},
+		Responses:   map[string]*Response{"200": invalidated},
+		Security:    b.security(),
+	}
+	b.errors(one, "404", "500")
+	b.add("DELETE", templatePath(routes.CachedReport), one)
+}
+
+// errorDescriptions explains each error status the API answers with
+var errorDescriptions = map[string]string{
+	"400": "Invalid request parameters; details lists each invalid field",
+	"401": "Missing or invalid bearer token",
+	"403": "The requested officer, unit or branch is outside the caller's scope",
+	"404": "Unknown resource",
+	"408": "The request was cancelled before the queries finished",
+	"500": "Unexpected server error",
+	"501": "Not supported by the configured store",
+	"503": "The database is unavailable",
+	"504": "The queries did not finish within the route's timeout",
+}
+
+// errors adds the shared error body under each status, plus the
+// authentication failures when authentication is enabled
+func (b *builder) errors(op *Operation, statuses ...string) {
+	if b.opts.Auth {
+		statuses = append(statuses, "401", "403")
+	}
+	body := b.errorSchema()
+	for _, status := range statuses {
+		op.Responses[status] = &Response{
+			Description: errorDescriptions[status],
+			Content:     map[string]*MediaType{"application/json": {Schema: body}},
+		}
+	}
+}
+
+// errorSchema registers apperr.Response as the Error component, with the codes
+// clients can switch on
+func (b *builder) errorSchema() *Schema {
+	const name = "Error"
+	if _, ok := b.schemas.defs[name]; !ok {
+		body := b.schemas.object(reflect.TypeFor[apperr.Response]())
+		body.Properties["code"].Enum = []string{
+			string(apperr.CodeValidation), string(apperr.CodeUnauthorized), string(apperr.CodeForbidden),
+			string(apperr.CodeNotFound), string(apperr.CodeMethodNotAllowed), string(apperr.CodeRequest),
+			string(apperr.CodeTimeout), string(apperr.CodeCanceled), string(apperr.CodeDatabaseUnavailable),
+			string(apperr.CodeSchemaMissing), string(apperr.CodeDatabase), string(apperr.CodeInternal),
+		}
+		body.Properties["details"].Description = "For VALIDATION_FAILED, the invalid fields as a list of {field, message}"
+		b.schemas.defs[name] = body
+		b.schemas.types[name] = reflect.TypeFor[apperr.Response]()
+	}
+	return ref(name)
+}
+
+// comparisonSchema registers comparison.Report as the Comparison component,
+// the body of the aggregate reports when compare is set
+func (b *builder) comparisonSchema() *Schema {
+	const name = "Comparison"
+	if _, ok := b.schemas.defs[name]; !ok {
+		b.schemas.defs[name] = b.schemas.object(reflect.TypeFor[comparison.Report]())
+		b.schemas.types[name] = reflect.TypeFor[comparison.Report]()
+	}
+	return ref(name)
+}
+
+// security is the requirement of the authenticated routes
+func (b *builder) security() []map[string][]string {
+	if !b.opts.Auth {
+		return nil
+	}
+	return []map[string][]string{{"bearerAuth": {}}}
+}
+
+// levelParam documents the level's filter parameter. It is optional on the
+// AO dashboard once authentication scopes the caller, covering every officer
+// in scope.
+func (b *builder) levelParam(level params.Level) *Parameter {
+	p := &Parameter{Name: string(level), In: "query", Required: true, Schema: &Schema{Type: "string"}}
+	switch level {
+	case params.LevelOfficer:
+		p.Description = "Account officer"
+		if b.opts.Auth {
+			p.Required = false
+			p.Description = "Account officer in the caller's scope; every officer in scope when absent"
+		}
+	case params.LevelUnit:
+		p.Description = "Unit name"
+	case params.LevelCenter:
+		p.Description = "Center name"
+	case params.LevelBranch:
+		p.Description = "Branch name from the role hierarchy"
+	}
+	return p
+}
+
+// dateParam documents a required YYYY-MM-DD date
+func dateParam(name, description string) *Parameter {
+	return &Parameter{Name: name, In: "query", Required: true, Description: description, Schema: &Schema{Type: "string", Format: "date"}}
+}
+
+// clientParams documents the client report's filter, sort and paging parameters
+func clientParams() []*Parameter {
+	keys := make([]string, 0, len(store.ClientSortKeys))
+	for key := range store.ClientSortKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	minLimit, maxLimit, minOffset := 1, params.MaxClientLimit, 0
+
+	return []*Parameter{
+		{Name: "member_status", In: "query", Description: "Member status to list; must be one of the configured dashboard.member_statuses", Schema: &Schema{Type: "string", Default: "Active"}},
+		{Name: "search", In: "query", Description: "Matches client names and CIDs, at most 100 characters", Schema: &Schema{Type: "string"}},
+		{Name: "sort", In: "query", Schema: &Schema{Type: "string", Enum: keys, Default: "client_name"}},
+		{Name: "order", In: "query", Schema: &Schema{Type: "string", Enum: []string{"asc", "desc"}, Default: "asc"}},
+		{Name: "limit", In: "query", Description: "Page size", Schema: &Schema{Type: "integer", Minimum: &minLimit, Maximum: &maxLimit, Default: params.DefaultClientLimit}},
+		{Name: "offset", In: "query", Description: "Rows to skip; cannot be combined with cursor", Schema: &Schema{Type: "integer", Minimum: &minOffset}},
+		{Name: "cursor", In: "query", Description: "X-Next-Cursor of the previous page", Schema: &Schema{Type: "string"}},
+	}
+}
+
+// compareParam documents the compare parameter of the aggregate reports
+func compareParam() *Parameter {
+	modes := make([]string, len(comparison.Modes))
+	for i, m := range comparison.Modes {
+		modes[i] = string(m)
+	}
+	return &Parameter{
+		Name:        "compare",
+		In:          "query",
+		Description: "Compare every value against an earlier range and return the Comparison body instead, as JSON only. previous_period is the range of the same length ending the day before start_date, whole calendar months against as many months before; previous_year is the same range one year earlier.",
+		Schema:      &Schema{Type: "string", Enum: modes},
+	}
+}
+
+// seriesParams documents the bucketing parameters of the trend reports
+func (b *builder) seriesParams() []*Parameter {
+	granularities := make([]string, len(timeseries.Granularities))
+	for i, g := range timeseries.Granularities {
+		granularities[i] = string(g)
+	}
+	days := make([]string, 0, 7)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		days = append(days, strings.ToLower(d.String()))
+	}
+
+	return []*Parameter{
+		{Name: "granularity", In: "query", Description: fmt.Sprintf("Period length; every period of the range is returned, zero when empty, up to %d periods, with its period_start and period_end. When absent, each month is split into the days 1-7, 8-14, 15-21, 22-28 and 29 to its end, labelled Week 1 to Week 5 without period dates, as in the original v1 responses.", timeseries.MaxPeriods), Schema: &Schema{Type: "string", Enum: granularities}},
+		{Name: "week_start", In: "query", Description: "First day of the periods of granularity=week; monday gives ISO 8601 weeks labelled like 2024-W18", Schema: &Schema{Type: "string", Enum: days, Default: strings.ToLower(b.opts.WeekStart.String())}},
+	}
+}
+
+// joinPath appends a group-relative path to its prefix, dropping the trailing
+// slash of the group root
+func joinPath(prefix, path string) string {
+	if path == "/" {
+		return prefix
+	}
+	return prefix + path
+}
+
+// operationID builds a camel-case ID such as aoDashboardCenterSummary
+func operationID(parts ...string) string {
+	var id strings.Builder
+	for _, part := range parts {
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			word = strings.ToLower(word)
+			if id.Len() > 0 {
+				word = strings.ToUpper(word[:1]) + word[1:]
+			}
+			id.WriteString(word)
+		}
+	}
+	return id.String()
+}
@@ -0,0 +1,141 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of the OpenAPI 3.0 schema object this package emits
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// timeType is rendered as an RFC 3339 string, as encoding/json does
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemas builds component schemas from Go types, following the same json
+// struct tags encoding/json uses, so the document describes exactly what the
+// handlers serialize
+type schemas struct {
+	defs map[string]*Schema
+	// types records the type behind each component to tell apart equally
+	// named types of different packages
+	types map[string]reflect.Type
+}
+
+func newSchemas() *schemas {
+	return &schemas{defs: map[string]*Schema{}, types: map[string]reflect.Type{}}
+}
+
+// of returns the schema of t, registering named structs as components and
+// referring to them by $ref
+func (s *schemas) of(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(s.of(t.Elem()))
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := t.Name()
+		if other, ok := s.types[name]; ok && other != t {
+			name = path.Base(t.PkgPath()) + name
+		}
+		if _, ok := s.types[name]; !ok {
+			// Register before recursing so self-referencing types terminate
+			s.types[name] = t
+			s.defs[name] = &Schema{}
+			*s.defs[name] = *s.object(t)
+		}
+		return ref(name)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	}
+	// Interfaces such as any accept every value
+	return &Schema{}
+}
+
+// object describes a struct's JSON fields; fields without omitempty are required
+func (s *schemas) object(t reflect.Type) *Schema {
+	obj := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			// Embedded struct fields are promoted into the parent object
+			embedded := s.object(f.Type)
+			for k, v := range embedded.Properties {
+				obj.Properties[k] = v
+			}
+			obj.Required = append(obj.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		obj.Properties[name] = s.of(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			obj.Required = append(obj.Required, name)
+		}
+	}
+	return obj
+}
+
+// ref refers to the named component schema
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// nullable also accepts null; a reference is wrapped because OpenAPI 3.0
+// ignores the siblings of $ref
+func nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		return &Schema{AllOf: []*Schema{s}, Nullable: true}
+	}
+	out := *s
+	out.Nullable = true
+	return &out
+}
@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Undocumented lists the paths Verify ignores: the document itself and its viewer
+var Undocumented = map[string]bool{
+	"/openapi.json": true,
+	"/docs":         true,
+}
+
+// Verify compares the document with the routes registered on the app and
+// reports every route without an operation and every operation without a
+// route. Fiber's implicit HEAD routes and the middleware entries of groups are
+// ignored.
+func Verify(doc *Document, routes []fiber.Route) error {
+	registered := map[string]bool{}
+	for _, r := range routes {
+		if r.Method != fiber.MethodGet && r.Method != fiber.MethodDelete {
+			continue
+		}
+		path := templatePath(r.Path)
+		if Undocumented[path] {
+			continue
+		}
+		registered[r.Method+" "+path] = true
+	}
+
+	documented := map[string]bool{}
+	for path, item := range doc.Paths {
+		if item.Get != nil {
+			documented[fiber.MethodGet+" "+path] = true
+		}
+		if item.Delete != nil {
+			documented[fiber.MethodDelete+" "+path] = true
+		}
+	}
+
+	var undocumented, unrouted []string
+	for route := range registered {
+		if !documented[route] {
+			undocumented = append(undocumented, route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			unrouted = append(unrouted, route)
+		}
+	}
+
+	var errs []error
+	if len(undocumented) > 0 {
+		sort.Strings(undocumented)
+		errs = append(errs, fmt.Errorf("routes missing from the OpenAPI document: %s", strings.Join(undocumented, ", ")))
+	}
+	if len(unrouted) > 0 {
+		sort.Strings(unrouted)
+		errs = append(errs, fmt.Errorf("documented operations without a route: %s", strings.Join(unrouted, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// templatePath converts a Fiber path such as /cache/:report into the OpenAPI
+// form /cache/{report}, dropping the trailing slash of group roots
+func templatePath(path string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + strings.TrimSuffix(name, "?") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
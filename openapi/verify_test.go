@@ -0,0 +1,217 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"rbi_backend/comparison"
+	handlers "rbi_backend/handlers/AO"
+	"rbi_backend/handlers/health"
+	"rbi_backend/handlers/metrics"
+	"rbi_backend/handlers/params"
+	"rbi_backend/metric"
+	"rbi_backend/routes"
+	"rbi_backend/store"
+	"rbi_backend/versioning"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newApp registers the routes as main does for opts
+func newApp(t *testing.T, opts Options) *fiber.App {
+	t.Helper()
+	registry := metric.NewRegistry()
+	for _, def := range opts.Metrics {
+		if err := registry.Register(def); err != nil {
+			t.Fatalf("register %s: %v", def.Name, err)
+		}
+	}
+
+	app := fiber.New()
+	noop := func(c *fiber.Ctx) error { return nil }
+	routes.Operations(app, health.NewHandler(), noop)
+	if opts.Cache && opts.Auth {
+		routes.CacheInvalidation(app, noop)
+	}
+	api := routes.API{Dashboards: handlers.NewHandler(store.NewMemoryStore(nil, nil))}
+	if len(opts.Metrics) > 0 {
+		api.Metrics = metrics.NewHandler(registry, nil, params.Options{})
+	}
+	api.Mount(app, versioning.V1, false)
+	if opts.Legacy {
+		api.Mount(app, "", true)
+	}
+	return app
+}
+
+func TestVerifyMatchesRoutes(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"minimal", Options{}},
+		{"legacy", Options{Legacy: true}},
+		{"cache without auth", Options{Cache: true}},
+		{"everything", Options{Auth: true, Cache: true, Legacy: true, Metrics: metric.Builtins()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Build(tt.opts)
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if err := Verify(doc, newApp(t, tt.opts).GetRoutes(true)); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyReportsDrift(t *testing.T) {
+	opts := Options{Legacy: true}
+	doc, err := Build(opts)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	delete(doc.Paths, versioning.V1+"/ao-dashboard/capital")
+
+	app := newApp(t, opts)
+	app.Get("/undocumented", func(c *fiber.Ctx) error { return nil })
+
+	err = Verify(doc, app.GetRoutes(true))
+	if err == nil {
+		t.Fatal("Verify accepted a drifted document")
+	}
+	for _, want := range []string{"GET /undocumented", "GET " + versioning.V1 + "/ao-dashboard/capital"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Verify error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestResponseSchemasMatchJSONTags(t *testing.T) {
+	doc, err := Build(Options{Metrics: metric.Builtins()})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	for _, d := range routes.Dashboards(false) {
+		for _, r := range d.Reports {
+			path := versioning.V1 + joinPath(d.Prefix, r.Path)
+			t.Run(path, func(t *testing.T) {
+				schema := okSchema(t, doc, path)
+				if r.Aggregate {
+					if len(schema.OneOf) != 2 {
+						t.Fatalf("aggregate report schema is not a oneOf of the report and the comparison")
+					}
+					checkSchema(t, doc, schema.OneOf[1], reflect.TypeFor[comparison.Report]())
+					schema = schema.OneOf[0]
+				}
+				checkSchema(t, doc, schema, r.Body)
+			})
+		}
+	}
+
+	t.Run("metric", func(t *testing.T) {
+		schema := okSchema(t, doc, versioning.V1+templatePath(routes.Metric))
+		if len(schema.OneOf) != 2 {
+			t.Fatalf("metric schema is not a oneOf of the rows and the comparison")
+		}
+		checkSchema(t, doc, schema.OneOf[0], reflect.TypeFor[metrics.MetricResponse]())
+		checkSchema(t, doc, schema.OneOf[1], reflect.TypeFor[metrics.ComparisonResponse]())
+	})
+}
+
+// okSchema returns the JSON schema of the 200 response of GET path
+func okSchema(t *testing.T, doc *Document, path string) *Schema {
+	t.Helper()
+	item, ok := doc.Paths[path]
+	if !ok || item.Get == nil {
+		t.Fatalf("GET %s is not documented", path)
+	}
+	ok200, ok := item.Get.Responses["200"]
+	if !ok || ok200.Content["application/json"] == nil {
+		t.Fatalf("GET %s documents no JSON 200 response", path)
+	}
+	return ok200.Content["application/json"].Schema
+}
+
+// resolve follows a component reference
+func resolve(t *testing.T, doc *Document, s *Schema) *Schema {
+	t.Helper()
+	if s.Ref == "" {
+		return s
+	}
+	def, ok := doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	if !ok {
+		t.Fatalf("dangling reference %s", s.Ref)
+	}
+	return def
+}
+
+// checkSchema compares the documented object with what encoding/json produces
+// for the zero value of typ: the always-present keys must be exactly the
+// required properties, each documented with the JSON type it is encoded as
+func checkSchema(t *testing.T, doc *Document, s *Schema, typ reflect.Type) {
+	t.Helper()
+	s = resolve(t, doc, s)
+	if typ.Kind() == reflect.Slice {
+		if s.Type != "array" || s.Items == nil {
+			t.Fatalf("%s is documented as %q, not an array", typ, s.Type)
+		}
+		s, typ = resolve(t, doc, s.Items), typ.Elem()
+	}
+
+	data, err := json.Marshal(reflect.New(typ).Elem().Interface())
+	if err != nil {
+		t.Fatalf("marshal %s: %v", typ, err)
+	}
+	var encoded map[string]any
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		t.Fatalf("%s does not encode as an object: %v", typ, err)
+	}
+
+	keys := make([]string, 0, len(encoded))
+	for key := range encoded {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	required := append([]string(nil), s.Required...)
+	sort.Strings(required)
+	if !reflect.DeepEqual(keys, required) {
+		t.Errorf("%s encodes keys %v but the schema requires %v", typ, keys, required)
+	}
+
+	for key, value := range encoded {
+		prop, ok := s.Properties[key]
+		if !ok {
+			t.Errorf("%s encodes %q, which is not documented", typ, key)
+			continue
+		}
+		prop = resolve(t, doc, prop)
+		var want []string
+		switch value.(type) {
+		case string:
+			want = []string{"string"}
+		case float64:
+			want = []string{"integer", "number"}
+		case bool:
+			want = []string{"boolean"}
+		case map[string]any:
+			want = []string{"object"}
+		default:
+			// null, such as a nil slice or pointer, carries no type to compare
+			continue
+		}
+		found := false
+		for _, w := range want {
+			found = found || prop.Type == w
+		}
+		if !found {
+			t.Errorf("%s.%s encodes as %v but is documented as %q", typ, key, want, prop.Type)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package pdfreport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rbi_backend/store"
+	"rbi_backend/timeseries"
+)
+
+// dateLayout is the ISO date format of the store filter dates
+const dateLayout = "2006-01-02"
+
+// Collect queries the store for one officer's snapshot over [start, end], with
+// the capital build-up per week starting on weekStart
+func Collect(ctx context.Context, s store.AODashboardStore, officer string, start, end time.Time, weekStart time.Weekday) (Snapshot, error) {
+	m := Snapshot{Officer: officer, Start: start, End: end, GeneratedAt: time.Now()}
+	f := store.Officer(officer, start.Format(dateLayout), end.Format(dateLayout))
+
+	var err error
+	if m.Counts, err = s.CountsByStatus(ctx, f); err != nil {
+		return m, fmt.Errorf("counts by status: %w", err)
+	}
+	if m.Loans, err = s.LoanTotalsByBillType(ctx, f); err != nil {
+		return m, fmt.Errorf("loan totals: %w", err)
+	}
+	if m.WeeklyCapital, err = s.WeeklyCapital(ctx, f, timeseries.Bucketing{Granularity: timeseries.Week, WeekStart: weekStart}); err != nil {
+		return m, fmt.Errorf("weekly capital build-up: %w", err)
+	}
+	if m.Centers, err = s.CenterSummary(ctx, f); err != nil {
+		return m, fmt.Errorf("center summary: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,326 @@
+package pdfreport
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"rbi_backend/store"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Branding is printed in the header of every page
+type Branding struct {
+	Organization string
+	// LogoFile is an optional PNG or JPEG drawn beside the organization name
+	LogoFile string
+}
+
+// Snapshot holds the data of one officer's performance report for a period
+type Snapshot struct {
+	Officer string
+	// Start and End are the first and last day of the period
+	Start       time.Time
+	End         time.Time
+	GeneratedAt time.Time
+
+	Counts        []store.Result
+	Loans         []store.LoanAccountResult
+	Centers       []store.CenterSummary
+	WeeklyCapital []store.WeeklyCapitalBuildUp
+}
+
+// Page geometry in millimetres
+const (
+	margin     = 15.0
+	rowHeight  = 6.0
+	barHeight  = 5.0
+	labelWidth = 45.0
+)
+
+// Render writes the performance report as an A4 PDF
+func Render(w io.Writer, m Snapshot, b Branding) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margin, margin+12, margin)
+	pdf.SetAutoPageBreak(true, margin+5)
+	pdf.SetCreationDate(m.GeneratedAt)
+	pdf.SetTitle(fmt.Sprintf("Performance Report - %s - %s", m.Officer, m.period()), true)
+	pdf.SetAuthor(b.Organization, true)
+	pdf.AliasNbPages("{nb}")
+
+	r := &renderer{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+	pdf.SetHeaderFunc(func() { r.header(b) })
+	pdf.SetFooterFunc(func() { r.footer(m.GeneratedAt) })
+
+	pdf.AddPage()
+	r.title(m)
+
+	r.section("Clients by Status")
+	r.table([]column{{"Particulars", 120, "L"}, {"Count", 60, "R"}}, len(m.Counts), func(i int) []string {
+		return []string{m.Counts[i].Particulars, formatCount(m.Counts[i].Count)}
+	})
+
+	r.section("Loan Accounts by Bill Type")
+	r.table([]column{{"Particulars", 90, "L"}, {"Count", 40, "R"}, {"Amount", 50, "R"}}, len(m.Loans), func(i int) []string {
+		l := m.Loans[i]
+		return []string{l.Particulars, formatCount(l.Count), formatAmount(l.Amount)}
+	})
+	labels, values := make([]string, len(m.Loans)), make([]float64, len(m.Loans))
+	for i, l := range m.Loans {
+		labels[i], values[i] = l.Particulars, l.Amount
+	}
+	r.barChart("Loan amount by bill type", labels, values)
+
+	r.section("Weekly Capital Build-Up")
+	r.table([]column{{"Title", 90, "L"}, {"Week", 40, "L"}, {"Total Capital", 50, "R"}}, len(m.WeeklyCapital), func(i int) []string {
+		c := m.WeeklyCapital[i]
+		return []string{c.Title, c.Week, formatAmount(c.TotalCapital)}
+	})
+	labels, values = weeklyTotals(m.WeeklyCapital)
+	r.barChart("Capital build-up per week", labels, values)
+
+	r.section("Center Summary")
+	r.table([]column{{"Center Name", 60, "L"}, {"Clients", 30, "R"}, {"With Loans", 30, "R"}, {"Without Loans", 30, "R"}, {"Past Due", 30, "R"}}, len(m.Centers), func(i int) []string {
+		c := m.Centers[i]
+		return []string{c.CenterName, formatCount(c.NoOfClients), formatCount(c.WithLoans), formatCount(c.WithoutLoans), formatCount(c.PastDue)}
+	})
+
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("pdfreport: %w", err)
+	}
+	return pdf.Output(w)
+}
+
+// period describes the reporting period, naming the month when it covers exactly one
+func (m Snapshot) period() string {
+	if m.Start.Day() == 1 && m.End.Equal(m.Start.AddDate(0, 1, -1)) {
+		return m.Start.Format("January 2006")
+	}
+	return m.Start.Format("Jan. 02, 2006") + " - " + m.End.Format("Jan. 02, 2006")
+}
+
+// renderer draws the report sections onto a PDF
+type renderer struct {
+	pdf *gofpdf.Fpdf
+	// tr converts UTF-8 text to the code page of the core fonts
+	tr func(string) string
+}
+
+// column is one column of a table: header, width in millimetres and alignment
+type column struct {
+	header string
+	width  float64
+	align  string
+}
+
+// header draws the branding band at the top of every page
+func (r *renderer) header(b Branding) {
+	pdf := r.pdf
+	x := margin
+	if b.LogoFile != "" {
+		pdf.ImageOptions(b.LogoFile, margin, 8, 0, 12, false, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+		x += 16
+	}
+
+	pageWidth, _ := pdf.GetPageSize()
+	pdf.SetXY(x, 10)
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.SetTextColor(20, 60, 120)
+	pdf.CellFormat(pageWidth-margin-x, 8, r.tr(b.Organization), "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.SetXY(margin, 10)
+	pdf.CellFormat(pageWidth-2*margin, 8, "Performance Report", "", 0, "R", false, 0, "")
+
+	pdf.SetDrawColor(20, 60, 120)
+	pdf.SetLineWidth(0.5)
+	pdf.Line(margin, 21, pageWidth-margin, 21)
+	pdf.SetLineWidth(0.2)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(margin, margin+12)
+}
+
+// footer draws the generation timestamp and page number
+func (r *renderer) footer(generatedAt time.Time) {
+	pdf := r.pdf
+	pageWidth, _ := pdf.GetPageSize()
+	pdf.SetY(-15)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.CellFormat((pageWidth-2*margin)/2, 6, "Generated "+generatedAt.Format("Jan. 02, 2006 15:04 MST"), "T", 0, "L", false, 0, "")
+	pdf.CellFormat((pageWidth-2*margin)/2, 6, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "T", 0, "R", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// title draws the report heading naming the officer and period
+func (r *renderer) title(m Snapshot) {
+	pdf := r.pdf
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 9, r.tr("Account Officer: "+m.Officer), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 7, "Reporting period: "+m.period(), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+}
+
+// section starts a new titled section, breaking the page if too little room is left
+func (r *renderer) section(title string) {
+	r.ensure(3 * rowHeight)
+	pdf := r.pdf
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.SetTextColor(20, 60, 120)
+	pdf.CellFormat(0, 8, r.tr(title), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// table draws a bordered table, repeating the header row after a page break
+func (r *renderer) table(columns []column, rows int, cells func(i int) []string) {
+	pdf := r.pdf
+	drawHeader := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(225, 232, 242)
+		for _, col := range columns {
+			pdf.CellFormat(col.width, rowHeight+1, r.tr(col.header), "1", 0, col.align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+
+	drawHeader()
+	if rows == 0 {
+		width := 0.0
+		for _, col := range columns {
+			width += col.width
+		}
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(width, rowHeight, "No data for this period", "1", 1, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		return
+	}
+
+	for i := 0; i < rows; i++ {
+		if r.ensure(rowHeight) {
+			drawHeader()
+		}
+		pdf.SetFillColor(245, 247, 250)
+		for j, text := range cells(i) {
+			pdf.CellFormat(columns[j].width, rowHeight, r.tr(text), "1", 0, columns[j].align, i%2 == 1, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+// barChart draws a horizontal bar per value, scaled to the largest magnitude;
+// negative values are drawn in red with their sign kept in the label
+func (r *renderer) barChart(title string, labels []string, values []float64) {
+	if len(values) == 0 {
+		return
+	}
+
+	max := 0.0
+	for _, v := range values {
+		max = math.Max(max, math.Abs(v))
+	}
+
+	pdf := r.pdf
+	r.ensure(float64(len(values))*(barHeight+2) + 10)
+	pdf.Ln(3)
+	pdf.SetFont("Helvetica", "I", 9)
+	pdf.CellFormat(0, 6, r.tr(title), "", 1, "L", false, 0, "")
+
+	pageWidth, _ := pdf.GetPageSize()
+	chartWidth := pageWidth - 2*margin - labelWidth - 30
+	pdf.SetFont("Helvetica", "", 8)
+	for i, v := range values {
+		y := pdf.GetY()
+		pdf.SetXY(margin, y)
+		pdf.CellFormat(labelWidth, barHeight, r.tr(truncate(labels[i], 28)), "", 0, "R", false, 0, "")
+
+		width := 0.0
+		if max > 0 {
+			width = chartWidth * math.Abs(v) / max
+		}
+		if v < 0 {
+			pdf.SetFillColor(200, 70, 60)
+		} else {
+			pdf.SetFillColor(40, 100, 170)
+		}
+		pdf.Rect(margin+labelWidth+2, y+0.5, width, barHeight-1, "F")
+		pdf.SetXY(margin+labelWidth+4+width, y)
+		pdf.CellFormat(30, barHeight, formatAmount(v), "", 0, "L", false, 0, "")
+		pdf.SetXY(margin, y+barHeight+2)
+	}
+}
+
+// ensure starts a new page when less than height millimetres remain, reporting whether it did
+func (r *renderer) ensure(height float64) bool {
+	pdf := r.pdf
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+height <= pageHeight-bottom {
+		return false
+	}
+	pdf.AddPage()
+	return true
+}
+
+// weeklyTotals sums the capital build-up of every title per week, in week order
+func weeklyTotals(rows []store.WeeklyCapitalBuildUp) ([]string, []float64) {
+	var weeks []string
+	totals := map[string]float64{}
+	for _, row := range rows {
+		if _, ok := totals[row.Week]; !ok {
+			weeks = append(weeks, row.Week)
+		}
+		totals[row.Week] += row.TotalCapital
+	}
+
+	values := make([]float64, len(weeks))
+	for i, week := range weeks {
+		values[i] = totals[week]
+	}
+	return weeks, values
+}
+
+// formatCount formats a count with thousands separators
+func formatCount(n int) string {
+	return groupThousands(strconv.Itoa(n))
+}
+
+// formatAmount formats a peso amount with thousands separators and two decimals
+func formatAmount(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+	return groupThousands(whole) + "." + frac
+}
+
+// groupThousands inserts commas into a string of digits with an optional sign
+func groupThousands(digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
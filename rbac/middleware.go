@@ -0,0 +1,42 @@
+package rbac
+
+import (
+	"slices"
+
+	"rbi_backend/apperr"
+	"rbi_backend/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// scopeKey is the c.Locals key holding the resolved Scope
+const scopeKey = "scope"
+
+// Middleware resolves the authenticated user's scope; it must run after the
+// auth middleware
+func Middleware(h *Hierarchy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if principal, ok := auth.FromCtx(c); ok {
+			c.Locals(scopeKey, h.Resolve(principal.Subject))
+		}
+		return c.Next()
+	}
+}
+
+// FromCtx returns the scope resolved by Middleware, if any
+func FromCtx(c *fiber.Ctx) (Scope, bool) {
+	s, ok := c.Locals(scopeKey).(Scope)
+	return s, ok
+}
+
+// RequireRole is middleware limiting a route to callers holding one of the
+// given roles; it must run after Middleware and lets every request through
+// when authentication is disabled
+func RequireRole(roles ...Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if s, ok := FromCtx(c); ok && !slices.Contains(roles, s.Role) {
+			return apperr.New(fiber.StatusForbidden, apperr.CodeForbidden, "Not permitted for role "+string(s.Role))
+		}
+		return c.Next()
+	}
+}
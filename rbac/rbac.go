@@ -0,0 +1,203 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role is a position in the branch hierarchy
+type Role string
+
+const (
+	RoleOfficer       Role = "officer"
+	RoleUnitHead      Role = "unit_head"
+	RoleBranchManager Role = "branch_manager"
+	RoleAreaManager   Role = "area_manager"
+	RoleAdmin         Role = "admin"
+)
+
+// Hierarchy is the organisation tree loaded from the hierarchy file. Users are
+// identified by the subject of their bearer token; officers by their
+// account_officer value.
+type Hierarchy struct {
+	Admins []string `yaml:"admins"`
+	Areas  []Area   `yaml:"areas"`
+}
+
+// Area groups branches under one or more area managers
+type Area struct {
+	Name     string   `yaml:"name"`
+	Managers []string `yaml:"managers"`
+	Branches []Branch `yaml:"branches"`
+}
+
+// Branch groups units under one or more branch managers
+type Branch struct {
+	Name     string   `yaml:"name"`
+	Managers []string `yaml:"managers"`
+	Units    []Unit   `yaml:"units"`
+}
+
+// Unit groups account officers under one or more unit heads; Name matches
+// customer_info.unit_name
+type Unit struct {
+	Name     string   `yaml:"name"`
+	Heads    []string `yaml:"heads"`
+	Officers []string `yaml:"officers"`
+}
+
+// Scope is the resolved set of data a user may see
+type Scope struct {
+	User string
+	Role Role
+	// All is set for admins, who see every officer
+	All      bool
+	Officers []string
+	Units    []string
+	Branches []string
+}
+
+// CanViewOfficer reports whether the officer is inside the scope
+func (s Scope) CanViewOfficer(officer string) bool {
+	return s.All || slices.Contains(s.Officers, officer)
+}
+
+// LoadHierarchy reads and validates a YAML hierarchy file
+func LoadHierarchy(path string) (*Hierarchy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("rbac: read hierarchy: %w", err)
+	}
+
+	var h Hierarchy
+	if err := yaml.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("rbac: parse hierarchy %s: %w", path, err)
+	}
+	if err := h.Validate(); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Validate checks that every node is named and every officer belongs to one unit
+func (h *Hierarchy) Validate() error {
+	var errs []error
+	officerUnit := map[string]string{}
+
+	for _, area := range h.Areas {
+		if area.Name == "" {
+			errs = append(errs, errors.New("area without a name"))
+		}
+		for _, branch := range area.Branches {
+			if branch.Name == "" {
+				errs = append(errs, fmt.Errorf("area %q: branch without a name", area.Name))
+			}
+			for _, unit := range branch.Units {
+				if unit.Name == "" {
+					errs = append(errs, fmt.Errorf("branch %q: unit without a name", branch.Name))
+				}
+				for _, officer := range unit.Officers {
+					if prev, ok := officerUnit[officer]; ok {
+						errs = append(errs, fmt.Errorf("officer %q is listed in units %q and %q", officer, prev, unit.Name))
+					}
+					officerUnit[officer] = unit.Name
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("rbac: invalid hierarchy: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Resolve returns the scope of a user, taking the highest role they hold. Users
+// not named anywhere in the hierarchy are treated as officers seeing only
+// themselves.
+func (h *Hierarchy) Resolve(user string) Scope {
+	if slices.Contains(h.Admins, user) {
+		return Scope{User: user, Role: RoleAdmin, All: true}
+	}
+
+	var areas []Area
+	var branches []Branch
+	var units []Unit
+	for _, area := range h.Areas {
+		if slices.Contains(area.Managers, user) {
+			areas = append(areas, area)
+			continue
+		}
+		for _, branch := range area.Branches {
+			if slices.Contains(branch.Managers, user) {
+				branches = append(branches, branch)
+				continue
+			}
+			for _, unit := range branch.Units {
+				if slices.Contains(unit.Heads, user) {
+					units = append(units, unit)
+				}
+			}
+		}
+	}
+
+	scope := Scope{User: user, Role: RoleOfficer}
+	switch {
+	case len(areas) > 0:
+		scope.Role = RoleAreaManager
+	case len(branches) > 0:
+		scope.Role = RoleBranchManager
+	case len(units) > 0:
+		scope.Role = RoleUnitHead
+	}
+
+	for _, area := range areas {
+		branches = append(branches, area.Branches...)
+	}
+	for _, branch := range branches {
+		scope.Branches = append(scope.Branches, branch.Name)
+		units = append(units, branch.Units...)
+	}
+	for _, unit := range units {
+		scope.Units = append(scope.Units, unit.Name)
+		scope.Officers = append(scope.Officers, unit.Officers...)
+	}
+
+	// An officer always sees their own clients, whatever else they manage
+	scope.Officers = append(scope.Officers, user)
+
+	scope.Officers = dedupe(scope.Officers)
+	scope.Units = dedupe(scope.Units)
+	scope.Branches = dedupe(scope.Branches)
+	return scope
+}
+
+// dedupe returns the sorted distinct values
+func dedupe(values []string) []string {
+	sort.Strings(values)
+	return slices.Compact(values)
+}
+
+// BranchUnits returns the names of the units in the named branch
+func (h *Hierarchy) BranchUnits(branch string) ([]string, bool) {
+	if h == nil {
+		return nil, false
+	}
+	for _, area := range h.Areas {
+		for _, b := range area.Branches {
+			if b.Name == branch {
+				units := make([]string, len(b.Units))
+				for i, unit := range b.Units {
+					units[i] = unit.Name
+				}
+				return units, true
+			}
+		}
+	}
+	return nil, false
+}
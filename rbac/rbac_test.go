@@ -0,0 +1,93 @@
+package rbac
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// hierarchy has two areas; the north one mirrors hierarchy.example.yaml
+func hierarchy() *Hierarchy {
+	return &Hierarchy{
+		Admins: []string{"admin"},
+		Areas: []Area{
+			{Name: "North", Managers: []string{"am.north"}, Branches: []Branch{
+				{Name: "Baguio", Managers: []string{"bm.baguio"}, Units: []Unit{
+					{Name: "Baguio 1", Heads: []string{"uh.baguio1"}, Officers: []string{"AO002", "AO001"}},
+					{Name: "Baguio 2", Heads: []string{"uh.baguio2"}, Officers: []string{"AO003"}},
+				}},
+			}},
+			{Name: "South", Managers: []string{"am.south"}, Branches: []Branch{
+				{Name: "Davao", Managers: []string{"bm.davao"}, Units: []Unit{
+					{Name: "Davao 1", Heads: []string{"uh.davao1", "bm.baguio"}, Officers: []string{"AO004"}},
+				}},
+			}},
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name string
+		user string
+		want Scope
+	}{
+		{"admin", "admin", Scope{User: "admin", Role: RoleAdmin, All: true}},
+		{"area manager", "am.north", Scope{User: "am.north", Role: RoleAreaManager,
+			Officers: []string{"AO001", "AO002", "AO003", "am.north"}, Units: []string{"Baguio 1", "Baguio 2"}, Branches: []string{"Baguio"}}},
+		{"branch manager also heading a unit elsewhere", "bm.baguio", Scope{User: "bm.baguio", Role: RoleBranchManager,
+			Officers: []string{"AO001", "AO002", "AO003", "AO004", "bm.baguio"}, Units: []string{"Baguio 1", "Baguio 2", "Davao 1"}, Branches: []string{"Baguio"}}},
+		{"unit head", "uh.baguio1", Scope{User: "uh.baguio1", Role: RoleUnitHead,
+			Officers: []string{"AO001", "AO002", "uh.baguio1"}, Units: []string{"Baguio 1"}}},
+		{"listed officer", "AO003", Scope{User: "AO003", Role: RoleOfficer, Officers: []string{"AO003"}}},
+		{"unlisted user", "AO999", Scope{User: "AO999", Role: RoleOfficer, Officers: []string{"AO999"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hierarchy().Resolve(tt.user); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve(%s) = %+v, want %+v", tt.user, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanViewOfficer(t *testing.T) {
+	h := hierarchy()
+	tests := []struct {
+		user, officer string
+		want          bool
+	}{
+		{"AO001", "AO001", true},
+		{"AO001", "AO002", false},
+		{"uh.baguio1", "AO002", true},
+		{"uh.baguio1", "AO003", false},
+		{"bm.baguio", "AO003", true},
+		{"am.north", "AO004", false},
+		{"am.south", "AO004", true},
+		{"admin", "anyone", true},
+	}
+	for _, tt := range tests {
+		if got := h.Resolve(tt.user).CanViewOfficer(tt.officer); got != tt.want {
+			t.Errorf("Resolve(%s).CanViewOfficer(%s) = %v, want %v", tt.user, tt.officer, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := hierarchy().Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	h := hierarchy()
+	h.Areas[1].Branches[0].Units[0].Officers = append(h.Areas[1].Branches[0].Units[0].Officers, "AO001")
+	h.Areas[0].Branches = append(h.Areas[0].Branches, Branch{})
+	err := h.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted a duplicate officer and an unnamed branch")
+	}
+	for _, want := range []string{`officer "AO001" is listed in units "Baguio 1" and "Davao 1"`, `area "North": branch without a name`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error %q does not mention %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package routes is the single table of the API's routes: main mounts it and
+// the OpenAPI document describes it, so a route cannot be added to one
+// without the other.
+package routes
+
+import (
+	"reflect"
+
+	"rbi_backend/export"
+	handlers "rbi_backend/handlers/AO"
+	"rbi_backend/handlers/health"
+	"rbi_backend/handlers/metrics"
+	"rbi_backend/handlers/params"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Paths of the routes outside the dashboards
+const (
+	Liveness     = "/healthz"
+	Readiness    = "/readyz"
+	Dependencies = "/health/dependencies"
+	// Prometheus is the scrape endpoint; it must be registered before the
+	// legacy /metrics group, whose authentication would otherwise also guard it
+	Prometheus   = "/metrics"
+	Cache        = "/cache"
+	CachedReport = "/cache/:report"
+
+	reportsGroup = "/reports"
+	monthly      = "/monthly"
+	metricsGroup = "/metrics"
+	metric       = "/:name"
+	// Monthly and Metric are relative to the root the API tree is mounted at
+	Monthly = reportsGroup + monthly
+	Metric  = metricsGroup + metric
+)
+
+// Report is one dashboard report route
+type Report struct {
+	Path string
+	// Name keys the report's cache entries, query timeout and Prometheus
+	// labels, and prefixes its downloads
+	Name    string
+	Summary string
+	// Body is the type of the JSON body
+	Body   reflect.Type
+	Handle func(*handlers.Handler, *fiber.Ctx) error
+	// Clients marks the routes taking the client report's paging parameters
+	Clients bool
+	// Series marks the trend reports taking the bucketing parameters
+	Series bool
+	// JSONOnly marks the routes without CSV and XLSX downloads
+	JSONOnly bool
+	// Aggregate marks the reports taking the compare parameter
+	Aggregate bool
+}
+
+// RowsOf is the JSON body of a report sending every row
+func RowsOf[T any](export.Report[T]) reflect.Type {
+	return reflect.TypeFor[[]T]()
+}
+
+// ObjectOf is the JSON body of a report sending its single row
+func ObjectOf[T any](export.Report[T]) reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// Dashboard is a level's route group
+type Dashboard struct {
+	Prefix  string
+	Level   params.Level
+	Tag     string
+	Reports []Report
+}
+
+// Dashboards lists the dashboard groups; the AO dashboard also serves the
+// capital build-up and the summary. The legacy tree keeps the original
+// spelling of the AO dashboard paths.
+func Dashboards(legacy bool) []Dashboard {
+	aoPrefix, aoLoans := "/ao-dashboard", "/total-loans"
+	if legacy {
+		aoPrefix, aoLoans = "/AO-dashboard", "/Total-loans"
+	}
+	reports := func(ao bool) []Report {
+		loans := "/total-loans"
+		if ao {
+			loans = aoLoans
+		}
+		rs := []Report{
+			{Path: "/", Name: export.TotalValues.Name, Summary: "Client counts by status", Body: RowsOf(export.TotalValues), Handle: (*handlers.Handler).GetTotalCountsClient, Aggregate: true},
+			{Path: loans, Name: export.LoanTotals.Name, Summary: "Loan account counts and amounts by bill type", Body: RowsOf(export.LoanTotals), Handle: (*handlers.Handler).GetLoanAccountTotals, Aggregate: true},
+			{Path: "/age-group", Name: export.AgeGroups.Name, Summary: "Client counts by age group", Body: ObjectOf(export.AgeGroups), Handle: (*handlers.Handler).GetAgeGroupCounts, Aggregate: true},
+		}
+		if ao {
+			rs = append(rs, Report{Path: "/capital", Name: export.CapitalBuildUp.Name, Summary: "Capital build-up total", Body: RowsOf(export.CapitalBuildUp), Handle: (*handlers.Handler).GetCapitalBuildUp, Aggregate: true})
+		}
+		rs = append(rs,
+			Report{Path: "/products-count", Name: export.ProductCounts.Name, Summary: "Loan counts by product", Body: RowsOf(export.ProductCounts), Handle: (*handlers.Handler).GetProductCounts, Aggregate: true},
+			Report{Path: "/center-summary", Name: export.CenterSummary.Name, Summary: "Clients, loans and past dues per center", Body: RowsOf(export.CenterSummary), Handle: (*handlers.Handler).GetCenterSummary, Aggregate: true},
+			Report{Path: "/weekly-client-count", Name: export.WeeklyCounts.Name, Summary: "Clients recognized per period and member status", Body: RowsOf(export.WeeklyCounts), Handle: (*handlers.Handler).GetWeeklyCustomerCount, Series: true},
+			Report{Path: "/weekly-capital-build", Name: export.WeeklyCapital.Name, Summary: "Capital build-up per period", Body: RowsOf(export.WeeklyCapital), Handle: (*handlers.Handler).GetWeeklyCapitalBuildUp, Series: true},
+			Report{Path: "/clients-report", Name: export.Clients.Name, Summary: "One page of the client list", Body: RowsOf(export.Clients), Handle: (*handlers.Handler).GetClients, Clients: true},
+		)
+		if ao {
+			rs = append(rs, Report{Path: "/summary", Name: "summary", Summary: "Every AO dashboard report in one document", Body: reflect.TypeFor[handlers.Summary](), Handle: (*handlers.Handler).GetSummary, Clients: true, Series: true, JSONOnly: true})
+		}
+		return rs
+	}
+	return []Dashboard{
+		{Prefix: aoPrefix, Level: params.LevelOfficer, Tag: "AO dashboard", Reports: reports(true)},
+		{Prefix: "/unit-dashboard", Level: params.LevelUnit, Tag: "Unit dashboard", Reports: reports(false)},
+		{Prefix: "/center-dashboard", Level: params.LevelCenter, Tag: "Center dashboard", Reports: reports(false)},
+		{Prefix: "/branch-dashboard", Level: params.LevelBranch, Tag: "Branch dashboard", Reports: reports(false)},
+	}
+}
+
+// Operations mounts the probes and the Prometheus scrape endpoint, served
+// without authentication
+func Operations(app fiber.Router, h *health.Handler, prometheus fiber.Handler) {
+	app.Get(Liveness, h.Live)
+	app.Get(Readiness, h.Ready)
+	app.Get(Dependencies, h.Dependencies)
+	app.Get(Prometheus, prometheus)
+}
+
+// CacheInvalidation mounts the routes clearing the response cache behind invalidate
+func CacheInvalidation(app fiber.Router, invalidate ...fiber.Handler) {
+	app.Delete(Cache, invalidate...)
+	app.Delete(CachedReport, invalidate...)
+}
+
+// API holds the handlers and middleware the API tree is mounted with
+type API struct {
+	Dashboards *handlers.Handler
+	// Metrics serves Metric; nil leaves the route out, as the memory store
+	// cannot run the metrics' SQL
+	Metrics *metrics.Handler
+	Filter  params.Options
+	// Auth authenticates and scopes every request of the tree
+	Auth []fiber.Handler
+	// Report wraps a dashboard report's handler, such as with its cache and
+	// query timeout; nil serves the handler alone
+	Report func(name string, h fiber.Handler) []fiber.Handler
+	// Monthly and Metric wrap the monthly report and the metric endpoint
+	Monthly func(h fiber.Handler) []fiber.Handler
+	Metric  func(h fiber.Handler) []fiber.Handler
+}
+
+// Mount registers the dashboards, the monthly report and the metric endpoint
+// under root, every group running middleware before authentication
+func (a API) Mount(app fiber.Router, root string, legacy bool, middleware ...fiber.Handler) {
+	group := func(prefix string, extra ...fiber.Handler) fiber.Router {
+		chain := append(append(append([]fiber.Handler{}, middleware...), a.Auth...), extra...)
+		return app.Group(root+prefix, chain...)
+	}
+
+	for _, d := range Dashboards(legacy) {
+		routes := group(d.Prefix, params.RequireDashboardFilter(d.Level, a.Filter), export.Middleware())
+		for _, r := range d.Reports {
+			handler := func(c *fiber.Ctx) error { return r.Handle(a.Dashboards, c) }
+			if a.Report != nil {
+				routes.Get(r.Path, a.Report(r.Name, handler)...)
+			} else {
+				routes.Get(r.Path, handler)
+			}
+		}
+	}
+
+	// Printable monthly performance report per account officer
+	group(reportsGroup).Get(monthly, append([]fiber.Handler{params.RequireMonthlyFilter()}, wrap(a.Monthly, a.Dashboards.GetMonthlyReport)...)...)
+
+	// Generic metric endpoint serving the registered metrics
+	if a.Metrics != nil {
+		group(metricsGroup).Get(metric, wrap(a.Metric, a.Metrics.GetMetric)...)
+	}
+}
+
+// wrap applies an optional wrapper to h
+func wrap(w func(fiber.Handler) []fiber.Handler, h fiber.Handler) []fiber.Handler {
+	if w == nil {
+		return []fiber.Handler{h}
+	}
+	return w(h)
+}
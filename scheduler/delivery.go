@@ -0,0 +1,197 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"rbi_backend/config"
+)
+
+// File is one generated report output
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Delivery is the set of files generated for one officer in one run
+type Delivery struct {
+	Job     string
+	Officer string
+	Start   time.Time
+	End     time.Time
+	Files   []File
+}
+
+// Deliverer sends a delivery to its destination
+type Deliverer interface {
+	Deliver(ctx context.Context, d Delivery) error
+}
+
+// DirDeliverer writes deliveries under Dir/<job>/<period end>/
+type DirDeliverer struct {
+	Dir string
+}
+
+// Deliver writes each file atomically so readers never see a partial report
+func (d DirDeliverer) Deliver(ctx context.Context, del Delivery) error {
+	dir := filepath.Join(d.Dir, del.Job, del.End.Format(dateLayout))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, f := range del.Files {
+		tmp, err := os.CreateTemp(dir, "."+f.Name+".*")
+		if err != nil {
+			return err
+		}
+		_, err = tmp.Write(f.Data)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), filepath.Join(dir, f.Name))
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return fmt.Errorf("write %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// smtpTimeout limits how long sending one message may take
+const smtpTimeout = 2 * time.Minute
+
+// SMTPDeliverer mails each delivery, with its files attached, to the configured recipients
+type SMTPDeliverer struct {
+	Config config.SMTPConfig
+}
+
+// Deliver sends one message per officer; STARTTLS is used when the server offers it
+func (d SMTPDeliverer) Deliver(ctx context.Context, del Delivery) error {
+	msg, err := d.message(del)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(d.Config.Host, strconv.Itoa(d.Config.Port))
+	conn, err := (&net.Dialer{Timeout: 30 * time.Second}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	// Bound the whole conversation so an unresponsive server cannot stall the job
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, d.Config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: d.Config.Host}); err != nil {
+			return err
+		}
+	}
+	if d.Config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", d.Config.Username, d.Config.Password, d.Config.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(d.Config.From); err != nil {
+		return err
+	}
+	for _, to := range d.Config.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message builds the multipart MIME message for a delivery
+func (d SMTPDeliverer) message(del Delivery) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	period := del.Start.Format(dateLayout) + " to " + del.End.Format(dateLayout)
+	text, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(text, "Attached are the %s performance reports for account officer %s covering %s.\r\n", del.Job, del.Officer, period)
+
+	for _, f := range del.Files {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {f.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": f.Name})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, f.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	header := []string{
+		"From: " + d.Config.From,
+		"To: " + strings.Join(d.Config.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", fmt.Sprintf("Performance report for %s (%s)", del.Officer, period)),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + mw.Boundary(),
+	}
+	msg.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// writeBase64 writes data base64 encoded in 76-character lines, as RFC 2045 requires
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+		if _, err := w.Write([]byte(encoded[:n] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
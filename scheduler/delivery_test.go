@@ -0,0 +1,199 @@
+package scheduler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rbi_backend/config"
+)
+
+// delivery returns the reports of ao1 for the first week of March
+func delivery() Delivery {
+	return Delivery{
+		Job:     "weekly",
+		Officer: "ao1",
+		Start:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		End:     time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC),
+		Files: []File{
+			{Name: "total-values.csv", ContentType: "text/csv", Data: []byte("status,count\r\nActive,3\r\n")},
+			{Name: "performance-report.pdf", ContentType: "application/pdf", Data: bytes.Repeat([]byte("%PDF"), 40)},
+		},
+	}
+}
+
+func TestDirDeliverer(t *testing.T) {
+	dir := t.TempDir()
+	del := delivery()
+	if err := (DirDeliverer{Dir: dir}).Deliver(context.Background(), del); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	out := filepath.Join(dir, "weekly", "2024-03-07")
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(del.Files) {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("%s holds %v, want only the %d delivered files", out, names, len(del.Files))
+	}
+	for _, f := range del.Files {
+		data, err := os.ReadFile(filepath.Join(out, f.Name))
+		if err != nil {
+			t.Errorf("read %s: %v", f.Name, err)
+			continue
+		}
+		if !bytes.Equal(data, f.Data) {
+			t.Errorf("%s = %q, want %q", f.Name, data, f.Data)
+		}
+	}
+}
+
+// smtpSink accepts one SMTP conversation on a local listener and returns the
+// envelope recipients and message through the channels it is given
+func smtpSink(t *testing.T, rcpts chan<- []string, messages chan<- []byte) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		reply("220 localhost ESMTP")
+		var to []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				to = append(to, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var msg bytes.Buffer
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					msg.WriteString(strings.TrimPrefix(line, "."))
+				}
+				rcpts <- to
+				messages <- msg.Bytes()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestSMTPDeliverer(t *testing.T) {
+	rcpts := make(chan []string, 1)
+	messages := make(chan []byte, 1)
+	port := smtpSink(t, rcpts, messages)
+
+	d := SMTPDeliverer{Config: config.SMTPConfig{
+		Host: "127.0.0.1",
+		Port: port,
+		From: "reports@example.com",
+		To:   []string{"ops@example.com", "audit@example.com"},
+	}}
+	del := delivery()
+	if err := d.Deliver(context.Background(), del); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	if got := <-rcpts; !reflect.DeepEqual(got, d.Config.To) {
+		t.Errorf("recipients = %v, want %v", got, d.Config.To)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(<-messages))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Performance report for ao1 (2024-03-01 to 2024-03-07)" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+
+	attachments := map[string][]byte{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		// multipart.Reader undoes the quoted-printable encoding only, so
+		// the base64 attachments are decoded here
+		if part.FileName() == "" {
+			continue
+		}
+		encoded, _ := io.ReadAll(part)
+		lines := strings.Split(strings.TrimSuffix(string(encoded), "\r\n"), "\r\n")
+		for _, line := range lines {
+			if len(line) > 76 {
+				t.Errorf("%s has a base64 line of %d characters", part.FileName(), len(line))
+			}
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.Join(lines, ""))
+		if err != nil {
+			t.Fatalf("decode %s: %v", part.FileName(), err)
+		}
+		attachments[part.FileName()] = data
+	}
+	if len(attachments) != len(del.Files) {
+		t.Errorf("message attaches %d files, want %d", len(attachments), len(del.Files))
+	}
+	for _, f := range del.Files {
+		if !bytes.Equal(attachments[f.Name], f.Data) {
+			t.Errorf("attachment %s = %q, want %q", f.Name, attachments[f.Name], f.Data)
+		}
+	}
+}
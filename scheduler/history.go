@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Run statuses recorded in the job history
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	// StatusPartial means some officers' reports failed and the rest were delivered
+	StatusPartial = "partial"
+	StatusFailed  = "failed"
+)
+
+// Run is one execution of a scheduled job, stored in the report_job_runs table
+type Run struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Job        string    `gorm:"size:100;not null;index" json:"job"`
+	Status     string    `gorm:"size:20;not null" json:"status"`
+	StartedAt  time.Time `gorm:"not null;index" json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
+	// PeriodStart and PeriodEnd are the dates the reports cover
+	PeriodStart string `gorm:"size:10" json:"period_start"`
+	PeriodEnd   string `gorm:"size:10" json:"period_end"`
+	Officers    int    `json:"officers"`
+	Failures    int    `json:"failures"`
+	Error       string `gorm:"type:text" json:"error,omitempty"`
+}
+
+// TableName is the job history table
+func (Run) TableName() string {
+	return "report_job_runs"
+}
+
+// History records job runs: Start when a run begins and Finish once its status is known
+type History interface {
+	Start(ctx context.Context, run *Run) error
+	Finish(ctx context.Context, run *Run) error
+}
+
+// GormHistory keeps the job history in the database
+type GormHistory struct {
+	db *gorm.DB
+}
+
+// NewGormHistory returns a history stored through db, creating the table if needed
+func NewGormHistory(db *gorm.DB) (*GormHistory, error) {
+	if err := db.AutoMigrate(&Run{}); err != nil {
+		return nil, err
+	}
+	return &GormHistory{db: db}, nil
+}
+
+// Start inserts the run, assigning its ID
+func (h *GormHistory) Start(ctx context.Context, run *Run) error {
+	return h.db.WithContext(ctx).Create(run).Error
+}
+
+// Finish saves the run's final status
+func (h *GormHistory) Finish(ctx context.Context, run *Run) error {
+	return h.db.WithContext(ctx).Save(run).Error
+}
+
+// MemoryHistory keeps the job history in memory, for runs without a database
+type MemoryHistory struct {
+	mu   sync.Mutex
+	runs []Run
+}
+
+// NewMemoryHistory returns an empty in-memory history
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{}
+}
+
+// Start appends the run, assigning its ID
+func (h *MemoryHistory) Start(ctx context.Context, run *Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	run.ID = uint(len(h.runs) + 1)
+	h.runs = append(h.runs, *run)
+	return nil
+}
+
+// Finish replaces the stored run with its final state
+func (h *MemoryHistory) Finish(ctx context.Context, run *Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i := int(run.ID) - 1; i >= 0 && i < len(h.runs) {
+		h.runs[i] = *run
+	}
+	return nil
+}
+
+// Runs returns every recorded run, oldest first
+func (h *MemoryHistory) Runs() []Run {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.runs)
+}
@@ -0,0 +1,246 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"rbi_backend/config"
+	"rbi_backend/export"
+	"rbi_backend/pdfreport"
+	"rbi_backend/store"
+
+	"github.com/robfig/cron/v3"
+)
+
+// dateLayout is the ISO date format used in file names and the history
+const dateLayout = "2006-01-02"
+
+// maxErrors caps how many officer failures are kept in a run's error text
+const maxErrors = 10
+
+// Scheduler runs the configured report jobs on their cron schedules
+type Scheduler struct {
+	cron       *cron.Cron
+	store      store.AODashboardStore
+	history    History
+	deliverers map[string]Deliverer
+	branding   pdfreport.Branding
+	// weekStart is the first day of the weeks of the capital build-up
+	weekStart time.Weekday
+	logger    *slog.Logger
+
+	// ctx is the parent of every run, cancelled when Stop gives up waiting
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New returns a scheduler with every job of cfg registered; call Start to begin running them
+func New(cfg config.ScheduleConfig, s store.AODashboardStore, h History, branding pdfreport.Branding, weekStart time.Weekday, logger *slog.Logger) (*Scheduler, error) {
+	sch := &Scheduler{
+		store:   s,
+		history: h,
+		deliverers: map[string]Deliverer{
+			"dir":  DirDeliverer{Dir: cfg.OutputDir},
+			"smtp": SMTPDeliverer{Config: cfg.SMTP},
+		},
+		branding:  branding,
+		weekStart: weekStart,
+		logger:    logger,
+	}
+	sch.ctx, sch.cancel = context.WithCancel(context.Background())
+
+	// A run still going when the next is due is skipped rather than overlapped
+	sch.cron = cron.New(cron.WithChain(cron.SkipIfStillRunning(cronLogger{logger})))
+	for _, job := range cfg.Jobs {
+		job := job
+		if _, err := sch.cron.AddFunc(job.Cron, func() { sch.Run(sch.ctx, job) }); err != nil {
+			return nil, fmt.Errorf("scheduler: job %q: %w", job.Name, err)
+		}
+	}
+	return sch, nil
+}
+
+// Start begins running jobs in the background
+func (s *Scheduler) Start() {
+	s.cron.Start()
+}
+
+// Stop stops scheduling new runs and waits for the running ones to finish.
+// When ctx ends first they are cancelled, which makes them skip their
+// remaining officers, and Stop returns ctx's error once they have wound down.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	done := s.cron.Stop()
+	select {
+	case <-done.Done():
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done.Done()
+		return ctx.Err()
+	}
+}
+
+// Run executes a job once for every officer and records it in the history.
+// One officer's failure does not stop the others; the run is then partial.
+func (s *Scheduler) Run(ctx context.Context, job config.JobConfig) Run {
+	now := time.Now()
+	start, end := period(job.Period, now)
+	run := Run{
+		Job:         job.Name,
+		Status:      StatusRunning,
+		StartedAt:   now,
+		PeriodStart: start.Format(dateLayout),
+		PeriodEnd:   end.Format(dateLayout),
+	}
+	logger := s.logger.With(slog.String("job", job.Name), slog.String("period_start", run.PeriodStart), slog.String("period_end", run.PeriodEnd))
+	if err := s.history.Start(ctx, &run); err != nil {
+		logger.Error("Could not record job start", slog.Any("error", err))
+	}
+	logger.Info("Scheduled job started")
+
+	var errs []string
+	officers, err := s.store.Officers(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("list officers: %v", err))
+	}
+	run.Officers = len(officers)
+	skipped := 0
+	for i, officer := range officers {
+		if ctx.Err() != nil {
+			skipped = len(officers) - i
+			logger.Warn("Scheduled job cancelled", slog.Int("skipped", skipped))
+			break
+		}
+		if err := s.runOfficer(ctx, job, officer, start, end); err != nil {
+			run.Failures++
+			logger.Error("Scheduled report failed", slog.String("account_officer", officer), slog.Any("error", err))
+			if len(errs) < maxErrors {
+				errs = append(errs, fmt.Sprintf("%s: %v", officer, err))
+			}
+		}
+	}
+
+	if run.Failures > len(errs) {
+		errs = append(errs, fmt.Sprintf("and %d more", run.Failures-len(errs)))
+	}
+	if skipped > 0 {
+		run.Failures += skipped
+		errs = append(errs, fmt.Sprintf("cancelled with %d officers left: %v", skipped, ctx.Err()))
+	}
+
+	switch {
+	case len(errs) == 0:
+		run.Status = StatusSucceeded
+	case run.Failures > 0 && run.Failures < run.Officers:
+		run.Status = StatusPartial
+	default:
+		run.Status = StatusFailed
+	}
+	run.Error = strings.Join(errs, "\n")
+	run.FinishedAt = time.Now()
+	run.DurationMS = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+
+	// Record the outcome even when the run was cancelled
+	if err := s.history.Finish(context.WithoutCancel(ctx), &run); err != nil {
+		logger.Error("Could not record job result", slog.Any("error", err))
+	}
+	logger.Info("Scheduled job finished",
+		slog.String("status", run.Status),
+		slog.Int("officers", run.Officers),
+		slog.Int("failures", run.Failures),
+		slog.Int64("duration_ms", run.DurationMS),
+	)
+	return run
+}
+
+// runOfficer generates and delivers one officer's reports
+func (s *Scheduler) runOfficer(ctx context.Context, job config.JobConfig, officer string, start, end time.Time) error {
+	files, err := s.generate(ctx, job.Formats, officer, start, end)
+	if err != nil {
+		return err
+	}
+
+	deliverer, ok := s.deliverers[job.Delivery]
+	if !ok {
+		return fmt.Errorf("unknown delivery %q", job.Delivery)
+	}
+	return deliverer.Deliver(ctx, Delivery{Job: job.Name, Officer: officer, Start: start, End: end, Files: files})
+}
+
+// generate renders the officer's snapshot in each format: a PDF report, or one
+// CSV per dashboard table
+func (s *Scheduler) generate(ctx context.Context, formats []string, officer string, start, end time.Time) ([]File, error) {
+	snapshot, err := pdfreport.Collect(ctx, s.store, officer, start, end, s.weekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := start.Format(dateLayout), end.Format(dateLayout)
+	var files []File
+	add := func(format export.Format, name string, write func(*bytes.Buffer) error) error {
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		files = append(files, File{Name: export.Filename(format, name, officer, from, to), ContentType: format.ContentType(), Data: buf.Bytes()})
+		return nil
+	}
+
+	var errs []error
+	for _, format := range formats {
+		switch export.Format(format) {
+		case export.PDF:
+			errs = append(errs, add(export.PDF, "performance-report", func(b *bytes.Buffer) error {
+				return pdfreport.Render(b, snapshot, s.branding)
+			}))
+		case export.CSV:
+			errs = append(errs,
+				add(export.CSV, export.TotalValues.Name, func(b *bytes.Buffer) error {
+					return export.TotalValues.WriteAll(export.CSV, b, snapshot.Counts)
+				}),
+				add(export.CSV, export.LoanTotals.Name, func(b *bytes.Buffer) error {
+					return export.LoanTotals.WriteAll(export.CSV, b, snapshot.Loans)
+				}),
+				add(export.CSV, export.WeeklyCapital.Name, func(b *bytes.Buffer) error {
+					return export.WeeklyCapital.WriteAll(export.CSV, b, snapshot.WeeklyCapital)
+				}),
+				add(export.CSV, export.CenterSummary.Name, func(b *bytes.Buffer) error {
+					return export.CenterSummary.WriteAll(export.CSV, b, snapshot.Centers)
+				}),
+			)
+		default:
+			errs = append(errs, fmt.Errorf("unknown format %q", format))
+		}
+	}
+	return files, errors.Join(errs...)
+}
+
+// period returns the first and last day a run at now reports on: the seven
+// days before it for "week", the previous calendar month for "month"
+func period(kind string, now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if kind == "month" {
+		first := today.AddDate(0, 0, 1-today.Day())
+		return first.AddDate(0, -1, 0), first.AddDate(0, 0, -1)
+	}
+	return today.AddDate(0, 0, -7), today.AddDate(0, 0, -1)
+}
+
+// cronLogger adapts slog to the cron package's logger
+type cronLogger struct {
+	logger *slog.Logger
+}
+
+func (l cronLogger) Info(msg string, keysAndValues ...any) {
+	l.logger.Debug("cron: "+msg, keysAndValues...)
+}
+
+func (l cronLogger) Error(err error, msg string, keysAndValues ...any) {
+	l.logger.Error("cron: "+msg, append(keysAndValues, slog.Any("error", err))...)
+}
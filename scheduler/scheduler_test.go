@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"rbi_backend/config"
+	"rbi_backend/pdfreport"
+	"rbi_backend/store"
+)
+
+func TestPeriod(t *testing.T) {
+	manila := time.FixedZone("PHT", 8*60*60)
+	tests := []struct {
+		name string
+		kind string
+		now  time.Time
+		want [2]string
+	}{
+		{"week before a run", "week", time.Date(2024, 3, 6, 15, 30, 0, 0, time.UTC), [2]string{"2024-02-28", "2024-03-05"}},
+		{"week across the year boundary", "week", time.Date(2024, 1, 3, 6, 0, 0, 0, time.UTC), [2]string{"2023-12-27", "2024-01-02"}},
+		{"previous month", "month", time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC), [2]string{"2024-02-01", "2024-02-29"}},
+		{"previous month from mid-month", "month", time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC), [2]string{"2024-04-01", "2024-04-30"}},
+		{"previous month across the year boundary", "month", time.Date(2024, 1, 15, 6, 0, 0, 0, time.UTC), [2]string{"2023-12-01", "2023-12-31"}},
+		{"calendar date of the run's zone", "month", time.Date(2024, 4, 1, 1, 0, 0, 0, manila), [2]string{"2024-03-01", "2024-03-31"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := period(tt.kind, tt.now)
+			if got := [2]string{start.Format(dateLayout), end.Format(dateLayout)}; got != tt.want {
+				t.Errorf("period(%s, %s) = %v, want %v", tt.kind, tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+// failingStore fails every report of one officer
+type failingStore struct {
+	*store.MemoryStore
+	officer string
+}
+
+func (s failingStore) CountsByStatus(ctx context.Context, f store.Filter) ([]store.Result, error) {
+	if len(f.Officers) == 1 && f.Officers[0] == s.officer {
+		return nil, errors.New("query failed")
+	}
+	return s.MemoryStore.CountsByStatus(ctx, f)
+}
+
+// officers returns a memory store listing ao1 and ao2, who have one loan each
+func officers() *store.MemoryStore {
+	opened := time.Now().AddDate(0, 0, -3)
+	return store.NewMemoryStore(nil, []store.LoanAccount{
+		{Customer: "C1", AccountOfficer: "ao1", BillType: "Regular", OpeningDate: opened, OnlineActualBal: -1000},
+		{Customer: "C2", AccountOfficer: "ao2", BillType: "Regular", OpeningDate: opened, OnlineActualBal: -500},
+	})
+}
+
+// newScheduler returns a scheduler over s delivering to dir and recording in a memory history
+func newScheduler(t *testing.T, s store.AODashboardStore, dir string) (*Scheduler, *MemoryHistory) {
+	t.Helper()
+	history := NewMemoryHistory()
+	sch, err := New(config.ScheduleConfig{OutputDir: dir}, s, history, pdfreport.Branding{Organization: "RBI"}, time.Monday, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return sch, history
+}
+
+func TestRunRecordsHistory(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		s        store.AODashboardStore
+		ctx      context.Context
+		delivery string
+		status   string
+		failures int
+		// errText is a fragment of the recorded error
+		errText string
+	}{
+		{"every officer delivered", officers(), context.Background(), "dir", StatusSucceeded, 0, ""},
+		{"one officer failing", failingStore{officers(), "ao2"}, context.Background(), "dir", StatusPartial, 1, "ao2: counts by status: query failed"},
+		{"every officer failing", officers(), context.Background(), "fax", StatusFailed, 2, `unknown delivery "fax"`},
+		{"cancelled before the first officer", officers(), cancelled, "dir", StatusFailed, 2, "cancelled with 2 officers left"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sch, history := newScheduler(t, tt.s, t.TempDir())
+			job := config.JobConfig{Name: "weekly", Period: "week", Formats: []string{"csv"}, Delivery: tt.delivery}
+
+			before := time.Now()
+			run := sch.Run(tt.ctx, job)
+
+			runs := history.Runs()
+			if len(runs) != 1 {
+				t.Fatalf("history holds %d runs, want 1", len(runs))
+			}
+			recorded := runs[0]
+			if recorded != run {
+				t.Errorf("recorded %+v, Run returned %+v", recorded, run)
+			}
+			if recorded.ID != 1 || recorded.Job != "weekly" || recorded.Status != tt.status || recorded.Officers != 2 || recorded.Failures != tt.failures {
+				t.Errorf("recorded %+v, want status %s with %d of 2 officers failing", recorded, tt.status, tt.failures)
+			}
+			if tt.errText == "" && recorded.Error != "" || !strings.Contains(recorded.Error, tt.errText) {
+				t.Errorf("recorded error %q, want it to contain %q", recorded.Error, tt.errText)
+			}
+
+			start, end := period("week", before)
+			if recorded.PeriodStart != start.Format(dateLayout) || recorded.PeriodEnd != end.Format(dateLayout) {
+				t.Errorf("recorded period %s to %s, want %s to %s", recorded.PeriodStart, recorded.PeriodEnd, start.Format(dateLayout), end.Format(dateLayout))
+			}
+			if recorded.StartedAt.Before(before) || recorded.FinishedAt.Before(recorded.StartedAt) {
+				t.Errorf("recorded run from %s to %s, started after %s", recorded.StartedAt, recorded.FinishedAt, before)
+			}
+			if want := recorded.FinishedAt.Sub(recorded.StartedAt).Milliseconds(); recorded.DurationMS != want {
+				t.Errorf("recorded duration %dms, want %dms", recorded.DurationMS, want)
+			}
+		})
+	}
+}
+
+func TestRunDeliversToDir(t *testing.T) {
+	dir := t.TempDir()
+	sch, _ := newScheduler(t, officers(), dir)
+	run := sch.Run(context.Background(), config.JobConfig{Name: "weekly", Period: "week", Formats: []string{"csv", "pdf"}, Delivery: "dir"})
+	if run.Status != StatusSucceeded {
+		t.Fatalf("run = %+v", run)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "weekly", run.PeriodEnd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	var want []string
+	for _, officer := range []string{"ao1", "ao2"} {
+		suffix := "_" + officer + "_" + run.PeriodStart + "_" + run.PeriodEnd
+		want = append(want,
+			"center-summary"+suffix+".csv",
+			"loan-totals"+suffix+".csv",
+			"performance-report"+suffix+".pdf",
+			"total-values"+suffix+".csv",
+			"weekly-capital-build-up"+suffix+".csv",
+		)
+	}
+	sort.Strings(want)
+	if strings.Join(names, "\n") != strings.Join(want, "\n") {
+		t.Errorf("delivered\n%s\nwant\n%s", strings.Join(names, "\n"), strings.Join(want, "\n"))
+	}
+}
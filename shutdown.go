@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"rbi_backend/cache"
+	database "rbi_backend/db"
+	"rbi_backend/scheduler"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Process exit codes
+const (
+	exitOK = 0
+	// exitServerError means the server failed to start or stopped on its own
+	exitServerError = 1
+	// exitShutdownIncomplete means requests or report jobs were still running
+	// at the shutdown deadline and were cut off
+	exitShutdownIncomplete = 2
+)
+
+// traceFlushTimeout bounds the span flush, which runs after the drain and so
+// cannot share its deadline
+const traceFlushTimeout = 5 * time.Second
+
+// shutdown stops accepting connections and, within timeout, drains in-flight
+// requests while the report jobs finish or are cancelled, then closes the
+// cache and the database pool and flushes pending spans within
+// traceFlushTimeout of their own. It reports whether everything finished in
+// time.
+func shutdown(app *fiber.App, sched *scheduler.Scheduler, redisStore *cache.Redis, flushTraces func(context.Context) error, timeout time.Duration, logger *slog.Logger) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	clean := true
+	var mu sync.Mutex
+	fail := func(msg string, err error) {
+		logger.Error(msg, slog.Any("error", err))
+		mu.Lock()
+		clean = false
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			fail("In-flight requests did not finish before the shutdown deadline", err)
+			return
+		}
+		logger.Info("HTTP server drained")
+	}()
+	if sched != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sched.Stop(ctx); err != nil {
+				fail("Report jobs were cancelled at the shutdown deadline", err)
+				return
+			}
+			logger.Info("Report scheduler stopped")
+		}()
+	}
+	wg.Wait()
+
+	if redisStore != nil {
+		if err := redisStore.Close(); err != nil {
+			fail("Could not close the cache connection", err)
+		}
+	}
+	if err := database.Close(); err != nil {
+		fail("Could not close the database pool", err)
+	}
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), traceFlushTimeout)
+	defer cancelFlush()
+	if err := flushTraces(flushCtx); err != nil {
+		fail("Could not flush pending spans", err)
+	}
+	return clean
+}
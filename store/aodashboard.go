@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"rbi_backend/timeseries"
+)
+
+// ErrUnsupportedFilter is returned when a query cannot honour the filter given,
+// such as get_capital_build_up, which only aggregates by account officer
+var ErrUnsupportedFilter = errors.New("store: filter not supported by this query")
+
+// Filter narrows the dashboard queries to a set of account officers and a date
+// range, optionally restricted further to units or centers
+type Filter struct {
+	// Officers lists the account officers to include; it is ignored when AllOfficers is set
+	Officers    []string
+	AllOfficers bool
+	// Units and Centers, when set, keep only clients in those units or centers
+	Units     []string
+	Centers   []string
+	StartDate string
+	EndDate   string
+}
+
+// Officer returns a filter for a single account officer
+func Officer(officer, startDate, endDate string) Filter {
+	return Filter{Officers: []string{officer}, StartDate: startDate, EndDate: endDate}
+}
+
+// includesOfficer reports whether the filter covers the given officer
+func (f Filter) includesOfficer(officer string) bool {
+	return f.AllOfficers || slices.Contains(f.Officers, officer)
+}
+
+// includesPlace reports whether the filter covers the given unit and center
+func (f Filter) includesPlace(unit, center string) bool {
+	return (len(f.Units) == 0 || slices.Contains(f.Units, unit)) &&
+		(len(f.Centers) == 0 || slices.Contains(f.Centers, center))
+}
+
+// AODashboardStore is the data-access layer behind the AO dashboard handlers
+type AODashboardStore interface {
+	// CountsByStatus returns the client count per member status plus a "Total Client" row
+	CountsByStatus(ctx context.Context, f Filter) ([]Result, error)
+	// LoanTotalsByBillType returns the loan count and amount per bill type
+	LoanTotalsByBillType(ctx context.Context, f Filter) ([]LoanAccountResult, error)
+	// CapitalBuildUp returns the capital build-up totals
+	CapitalBuildUp(ctx context.Context, f Filter) ([]CapitalBuildUpResult, error)
+	// AgeGroups returns the client count per age bracket
+	AgeGroups(ctx context.Context, f Filter) (AgeGroupCount, error)
+	// ProductCounts returns the loan count per product
+	ProductCounts(ctx context.Context, f Filter) ([]ProductCount, error)
+	// CenterSummary returns the client summary per center plus a "Total Centers" row
+	CenterSummary(ctx context.Context, f Filter) ([]CenterSummary, error)
+	// WeeklyCounts returns the client count per member status, plus a "Total
+	// Client" row, for every period of b overlapping the filter's date range
+	WeeklyCounts(ctx context.Context, f Filter, b timeseries.Bucketing) ([]WeeklyCount, error)
+	// WeeklyCapital returns the capital build-up for every period of b
+	// overlapping the filter's date range
+	WeeklyCapital(ctx context.Context, f Filter, b timeseries.Bucketing) ([]WeeklyCapitalBuildUp, error)
+	// ListClients returns one sorted, searched page of the clients with the query's member status
+	ListClients(ctx context.Context, f Filter, q ClientQuery) (ClientPage, error)
+	// Officers returns every account officer with loan accounts, sorted by name
+	Officers(ctx context.Context) ([]string, error)
+	// StreamClients returns every client matching the query in its sort order,
+	// ignoring Limit, Offset and Cursor; the caller must close the rows
+	StreamClients(ctx context.Context, f Filter, q ClientQuery) (ClientRows, error)
+}
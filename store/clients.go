@@ -0,0 +1,143 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// was issued for a different sort order
+var ErrInvalidCursor = errors.New("store: invalid cursor")
+
+// ClientSortKeys maps the sort keys accepted by ListClients onto the SQL
+// expressions they order by; NULLs are coalesced so keyset pagination is stable
+var ClientSortKeys = map[string]string{
+	"unit_name":       "COALESCE(ci.unit_name, '')",
+	"center_name":     "COALESCE(ci.center_name, '')",
+	"cid":             "ci.t_id::text",
+	"client_name":     "COALESCE(ci.customer_name, '')",
+	"date_recognized": "COALESCE(ci.l_date_recog::date, DATE '0001-01-01')",
+	"member_status":   "COALESCE(ci.member_status, '')",
+}
+
+// ClientQuery selects one page of the client report
+type ClientQuery struct {
+	MemberStatus string
+	// Search matches case-insensitively against the client name or CID
+	Search string
+	// Sort is a key of ClientSortKeys; ties are broken by CID
+	Sort string
+	Desc bool
+	// Limit caps the page size; Offset skips rows when no Cursor is given
+	Limit  int
+	Offset int
+	// Cursor continues after the last row of a previous page
+	Cursor string
+}
+
+// ClientPage is one page of the client report
+type ClientPage struct {
+	Clients []ActiveClientInfo
+	// Total counts every matching client, ignoring pagination
+	Total int
+	// NextCursor continues after this page; empty on the last page
+	NextCursor string
+}
+
+// ClientRows iterates over every client matching a ClientQuery, ignoring its
+// pagination, so large exports never hold the whole report in memory
+type ClientRows interface {
+	// Next advances to the next client, returning false when the rows are
+	// exhausted or iteration failed
+	Next() bool
+	// Client returns the current client
+	Client() ActiveClientInfo
+	// Err returns the error that stopped iteration, if any
+	Err() error
+	// Close releases the underlying cursor
+	Close() error
+}
+
+// sliceClientRows is a ClientRows over an in-memory slice
+type sliceClientRows struct {
+	rows []clientRow
+	pos  int
+}
+
+func (r *sliceClientRows) Next() bool {
+	if r.pos >= len(r.rows) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *sliceClientRows) Client() ActiveClientInfo { return r.rows[r.pos-1].info }
+func (r *sliceClientRows) Err() error               { return nil }
+func (r *sliceClientRows) Close() error             { return nil }
+
+// clientCursor is the decoded form of ClientQuery.Cursor
+type clientCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	CID   string `json:"c"`
+}
+
+// encodeCursor returns the opaque cursor continuing after the given row
+func encodeCursor(q ClientQuery, value, cid string) string {
+	data, _ := json.Marshal(clientCursor{Sort: q.Sort, Desc: q.Desc, Value: value, CID: cid})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses q.Cursor and checks it matches the requested order
+func decodeCursor(q ClientQuery) (*clientCursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cur clientCursor
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cur.Sort != q.Sort || cur.Desc != q.Desc {
+		return nil, ErrInvalidCursor
+	}
+	return &cur, nil
+}
+
+// CheckCursor reports ErrInvalidCursor when q.Cursor is set but was not
+// issued by a previous page of the same sort order
+func (q ClientQuery) CheckCursor() error {
+	_, err := decodeCursor(q)
+	return err
+}
+
+// escapeLike escapes the LIKE wildcards in a search term
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// sortClients orders in-memory rows by the sort value and CID, as the SQL does
+func sortClients(rows []clientRow, desc bool) {
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.sortValue != b.sortValue {
+			return (a.sortValue < b.sortValue) != desc
+		}
+		return (a.info.CID < b.info.CID) != desc
+	})
+}
+
+// clientRow pairs a report row with the value it is sorted by
+type clientRow struct {
+	info      ActiveClientInfo
+	sortValue string
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// tied returns a store whose active clients share unit names, so paging by
+// unit relies on the CID to break ties
+func tied() *MemoryStore {
+	var customers []Customer
+	for _, c := range [][2]string{{"C5", "U1"}, {"C3", "U1"}, {"C1", "U1"}, {"C4", "U2"}, {"C2", "U2"}} {
+		customers = append(customers, Customer{TID: c[0], CustomerName: "Client " + c[0], AccountOfficer: "ao1", UnitName: c[1], MemberStatus: "Active", DateRecognized: date("2024-03-01")})
+	}
+	return NewMemoryStore(customers, nil)
+}
+
+// walk follows the cursors from the first page of q and returns the CIDs of each page
+func walk(t *testing.T, s *MemoryStore, q ClientQuery) [][]string {
+	t.Helper()
+	var pages [][]string
+	for {
+		page, err := s.ListClients(context.Background(), march(nil), q)
+		if err != nil {
+			t.Fatalf("ListClients(%+v): %v", q, err)
+		}
+		var cids []string
+		for _, c := range page.Clients {
+			cids = append(cids, c.CID)
+		}
+		pages = append(pages, cids)
+		if page.NextCursor == "" {
+			return pages
+		}
+		if len(pages) > 10 {
+			t.Fatalf("ListClients(%+v) keeps returning a cursor", q)
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+func TestListClientsCursorWalk(t *testing.T) {
+	tests := []struct {
+		name string
+		s    *MemoryStore
+		q    ClientQuery
+		want [][]string
+	}{
+		{"by name", seed(), ClientQuery{MemberStatus: "Active", Sort: "client_name", Limit: 2},
+			[][]string{{"C1", "C2"}, {"C4"}}},
+		{"exact multiple of the limit", seed(), ClientQuery{MemberStatus: "Active", Sort: "cid", Limit: 3},
+			[][]string{{"C1", "C2", "C4"}}},
+		{"ties broken by CID", tied(), ClientQuery{MemberStatus: "Active", Sort: "unit_name", Limit: 2},
+			[][]string{{"C1", "C3"}, {"C5", "C2"}, {"C4"}}},
+		{"ties broken by CID descending", tied(), ClientQuery{MemberStatus: "Active", Sort: "unit_name", Desc: true, Limit: 2},
+			[][]string{{"C4", "C2"}, {"C5", "C3"}, {"C1"}}},
+		{"ties within one page", tied(), ClientQuery{MemberStatus: "Active", Sort: "unit_name", Limit: 1},
+			[][]string{{"C1"}, {"C3"}, {"C5"}, {"C2"}, {"C4"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := walk(t, tt.s, tt.q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListClientsRejectsForeignCursor(t *testing.T) {
+	s := tied()
+	q := ClientQuery{MemberStatus: "Active", Sort: "unit_name", Limit: 2}
+	page, err := s.ListClients(context.Background(), march(nil), q)
+	if err != nil || page.NextCursor == "" {
+		t.Fatalf("ListClients = %v, cursor %q", err, page.NextCursor)
+	}
+
+	tests := []struct {
+		name string
+		q    ClientQuery
+	}{
+		{"different sort", ClientQuery{MemberStatus: "Active", Sort: "client_name", Limit: 2, Cursor: page.NextCursor}},
+		{"different order", ClientQuery{MemberStatus: "Active", Sort: "unit_name", Desc: true, Limit: 2, Cursor: page.NextCursor}},
+		{"not base64", ClientQuery{MemberStatus: "Active", Sort: "unit_name", Limit: 2, Cursor: "not a cursor!"}},
+		{"not JSON", ClientQuery{MemberStatus: "Active", Sort: "unit_name", Limit: 2, Cursor: "bm90IGpzb24"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.q.CheckCursor(); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("CheckCursor = %v, want ErrInvalidCursor", err)
+			}
+			if _, err := s.ListClients(context.Background(), march(nil), tt.q); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("ListClients = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	q.Cursor = page.NextCursor
+	if err := q.CheckCursor(); err != nil {
+		t.Errorf("CheckCursor rejected the cursor of its own sort: %v", err)
+	}
+}
@@ -0,0 +1,500 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rbi_backend/timeseries"
+)
+
+// dateLayout is the ISO date format used for the filter dates
+const dateLayout = "2006-01-02"
+
+// Customer is an in-memory row of public.customer_info
+type Customer struct {
+	TID            string
+	CustomerName   string
+	AccountOfficer string
+	UnitName       string
+	CenterName     string
+	MemberStatus   string
+	DateRecognized time.Time
+	DateOfBirth    time.Time
+}
+
+// LoanAccount is an in-memory row of public.loan_acct
+type LoanAccount struct {
+	Customer        string
+	AccountOfficer  string
+	BillType        string
+	BillStatus      string
+	AccountTitle    string
+	OpeningDate     time.Time
+	OnlineActualBal float64
+}
+
+// MemoryStore implements AODashboardStore over in-memory rows, mirroring the
+// Postgres queries so dashboard logic can be exercised without a database
+type MemoryStore struct {
+	mu        sync.RWMutex
+	customers []Customer
+	loans     []LoanAccount
+	now       func() time.Time
+}
+
+// NewMemoryStore returns a store seeded with the given rows
+func NewMemoryStore(customers []Customer, loans []LoanAccount) *MemoryStore {
+	return &MemoryStore{
+		customers: append([]Customer(nil), customers...),
+		loans:     append([]LoanAccount(nil), loans...),
+		now:       time.Now,
+	}
+}
+
+// AddCustomer appends a customer row
+func (s *MemoryStore) AddCustomer(c Customer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.customers = append(s.customers, c)
+}
+
+// AddLoan appends a loan account row
+func (s *MemoryStore) AddLoan(l LoanAccount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loans = append(s.loans, l)
+}
+
+// dateRange parses the filter dates into an inclusive range
+func dateRange(f Filter) (time.Time, time.Time, error) {
+	start, err := time.Parse(dateLayout, f.StartDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("store: invalid start date %q: %w", f.StartDate, err)
+	}
+	end, err := time.Parse(dateLayout, f.EndDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("store: invalid end date %q: %w", f.EndDate, err)
+	}
+	return start, end, nil
+}
+
+// inRange reports whether the calendar date of t falls within [start, end]
+func inRange(t, start, end time.Time) bool {
+	d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return !d.Before(start) && !d.After(end)
+}
+
+// filterCustomers returns the customers matching the filter
+func (s *MemoryStore) filterCustomers(f Filter) ([]Customer, error) {
+	start, end, err := dateRange(f)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Customer
+	for _, c := range s.customers {
+		if f.includesOfficer(c.AccountOfficer) && f.includesPlace(c.UnitName, c.CenterName) && inRange(c.DateRecognized, start, end) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// filterLoans returns the loan accounts matching the filter
+func (s *MemoryStore) filterLoans(f Filter) ([]LoanAccount, error) {
+	start, end, err := dateRange(f)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Unit and center filters apply through the loan's customer
+	var owners map[string]Customer
+	if len(f.Units) > 0 || len(f.Centers) > 0 {
+		owners = make(map[string]Customer, len(s.customers))
+		for _, c := range s.customers {
+			owners[c.TID] = c
+		}
+	}
+
+	var out []LoanAccount
+	for _, l := range s.loans {
+		if !f.includesOfficer(l.AccountOfficer) || !inRange(l.OpeningDate, start, end) {
+			continue
+		}
+		if owners != nil {
+			owner, ok := owners[l.Customer]
+			if !ok || !f.includesPlace(owner.UnitName, owner.CenterName) {
+				continue
+			}
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// ageAt returns the age in whole years at the given time
+func ageAt(dob, now time.Time) int {
+	age := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// CountsByStatus returns the client count per member status plus a "Total Client" row
+func (s *MemoryStore) CountsByStatus(ctx context.Context, f Filter) ([]Result, error) {
+	customers, err := s.filterCustomers(f)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, c := range customers {
+		counts[c.MemberStatus]++
+	}
+
+	results := make([]Result, 0, len(counts)+1)
+	for status, count := range counts {
+		results = append(results, Result{Particulars: status, Count: count})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Particulars < results[j].Particulars })
+
+	return append(results, Result{Particulars: "Total Client", Count: len(customers)}), nil
+}
+
+// LoanTotalsByBillType returns the loan count and amount per bill type
+func (s *MemoryStore) LoanTotalsByBillType(ctx context.Context, f Filter) ([]LoanAccountResult, error) {
+	loans, err := s.filterLoans(f)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := map[string]*LoanAccountResult{}
+	var order []string
+	for _, l := range loans {
+		t, ok := totals[l.BillType]
+		if !ok {
+			t = &LoanAccountResult{Particulars: l.BillType}
+			totals[l.BillType] = t
+			order = append(order, l.BillType)
+		}
+		if l.BillType != "" {
+			t.Count++
+		}
+		t.Amount -= l.OnlineActualBal
+	}
+	sort.Strings(order)
+
+	results := make([]LoanAccountResult, 0, len(order))
+	for _, billType := range order {
+		results = append(results, *totals[billType])
+	}
+	return results, nil
+}
+
+// CapitalBuildUp returns the capital build-up total over billed loan accounts
+func (s *MemoryStore) CapitalBuildUp(ctx context.Context, f Filter) ([]CapitalBuildUpResult, error) {
+	if len(f.Units) > 0 || len(f.Centers) > 0 {
+		return nil, ErrUnsupportedFilter
+	}
+
+	loans, err := s.filterLoans(f)
+	if err != nil {
+		return nil, err
+	}
+
+	result := CapitalBuildUpResult{Title: "Capital Build Up"}
+	for _, l := range loans {
+		if l.BillType != "" {
+			result.TotalCapital -= l.OnlineActualBal
+		}
+	}
+	return []CapitalBuildUpResult{result}, nil
+}
+
+// Officers returns every account officer with loan accounts, sorted by name
+func (s *MemoryStore) Officers(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var officers []string
+	for _, l := range s.loans {
+		if l.AccountOfficer != "" && !slices.Contains(officers, l.AccountOfficer) {
+			officers = append(officers, l.AccountOfficer)
+		}
+	}
+	sort.Strings(officers)
+	return officers, nil
+}
+
+// AgeGroups returns the client count per age bracket
+func (s *MemoryStore) AgeGroups(ctx context.Context, f Filter) (AgeGroupCount, error) {
+	var result AgeGroupCount
+
+	customers, err := s.filterCustomers(f)
+	if err != nil {
+		return result, err
+	}
+
+	now := s.now()
+	for _, c := range customers {
+		switch age := ageAt(c.DateOfBirth, now); {
+		case age >= 80:
+			result.Age80Plus++
+		case age >= 70:
+			result.Age70_79++
+		case age >= 60:
+			result.Age60_69++
+		case age >= 50:
+			result.Age50_59++
+		case age >= 40:
+			result.Age40_49++
+		case age >= 30:
+			result.Age30_39++
+		case age >= 18:
+			result.Age18_29++
+		}
+		result.Total++
+	}
+	return result, nil
+}
+
+// ProductCounts returns the loan count per product
+func (s *MemoryStore) ProductCounts(ctx context.Context, f Filter) ([]ProductCount, error) {
+	loans, err := s.filterLoans(f)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, l := range loans {
+		if l.AccountTitle != "" {
+			counts[l.AccountTitle]++
+		}
+	}
+
+	results := make([]ProductCount, 0, len(counts))
+	for product, count := range counts {
+		results = append(results, ProductCount{ProductName: product, Count: count})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ProductName < results[j].ProductName })
+	return results, nil
+}
+
+// CenterSummary returns the client summary per center plus a "Total Centers" row
+func (s *MemoryStore) CenterSummary(ctx context.Context, f Filter) ([]CenterSummary, error) {
+	customers, err := s.filterCustomers(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// The loan join is on customer only, matching the LEFT JOIN in the SQL
+	s.mu.RLock()
+	withLoan := map[string]bool{}
+	pastDue := map[string]bool{}
+	for _, l := range s.loans {
+		withLoan[l.Customer] = true
+		if l.BillStatus == "DUE" {
+			pastDue[l.Customer] = true
+		}
+	}
+	s.mu.RUnlock()
+
+	type seen map[string]bool
+	centers := map[string]*CenterSummary{}
+	clients := map[string]seen{}
+	total := CenterSummary{CenterName: "Total Centers"}
+	totalSeen := seen{}
+
+	add := func(sum *CenterSummary, ids seen, tid string) {
+		if ids[tid] {
+			return
+		}
+		ids[tid] = true
+		sum.NoOfClients++
+		if withLoan[tid] {
+			sum.WithLoans++
+		} else {
+			sum.WithoutLoans++
+		}
+		if pastDue[tid] {
+			sum.PastDue++
+		}
+	}
+
+	for _, c := range customers {
+		sum, ok := centers[c.CenterName]
+		if !ok {
+			sum = &CenterSummary{CenterName: c.CenterName}
+			centers[c.CenterName] = sum
+			clients[c.CenterName] = seen{}
+		}
+		add(sum, clients[c.CenterName], c.TID)
+		add(&total, totalSeen, c.TID)
+	}
+
+	results := make([]CenterSummary, 0, len(centers)+1)
+	for _, sum := range centers {
+		results = append(results, *sum)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].CenterName < results[j].CenterName })
+
+	return append(results, total), nil
+}
+
+// WeeklyCounts returns the client count per member status, plus a "Total
+// Client" row, for every period of b overlapping the filter's date range
+func (s *MemoryStore) WeeklyCounts(ctx context.Context, f Filter, b timeseries.Bucketing) ([]WeeklyCount, error) {
+	customers, err := s.filterCustomers(f)
+	if err != nil {
+		return nil, err
+	}
+	series, err := newSeries[int](f, b)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range customers {
+		series.Add(c.MemberStatus, c.DateRecognized, 1)
+		series.Add(totalClient, c.DateRecognized, 1)
+	}
+	return weeklyCounts(series), nil
+}
+
+// WeeklyCapital returns the capital build-up for every period of b
+// overlapping the filter's date range
+func (s *MemoryStore) WeeklyCapital(ctx context.Context, f Filter, b timeseries.Bucketing) ([]WeeklyCapitalBuildUp, error) {
+	loans, err := s.filterLoans(f)
+	if err != nil {
+		return nil, err
+	}
+	series, err := newSeries[float64](f, b)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range loans {
+		if l.BillType != "" {
+			series.Add(capitalBuildUp, l.OpeningDate, -l.OnlineActualBal)
+		}
+	}
+	return weeklyCapital(series), nil
+}
+
+// ListClients returns one sorted, searched page of the clients with the query's member status
+func (s *MemoryStore) ListClients(ctx context.Context, f Filter, q ClientQuery) (ClientPage, error) {
+	var page ClientPage
+
+	cursor, err := decodeCursor(q)
+	if err != nil {
+		return page, err
+	}
+	rows, err := s.matchingClients(f, q)
+	if err != nil {
+		return page, err
+	}
+	page.Total = len(rows)
+
+	start := q.Offset
+	if cursor != nil {
+		// The first row strictly after the cursor in the requested order
+		start = sort.Search(len(rows), func(i int) bool {
+			r := rows[i]
+			if q.Desc {
+				return r.sortValue < cursor.Value || (r.sortValue == cursor.Value && r.info.CID < cursor.CID)
+			}
+			return r.sortValue > cursor.Value || (r.sortValue == cursor.Value && r.info.CID > cursor.CID)
+		})
+	}
+	if start > len(rows) {
+		start = len(rows)
+	}
+	end := start + q.Limit
+	if end > len(rows) {
+		end = len(rows)
+	}
+
+	for _, r := range rows[start:end] {
+		page.Clients = append(page.Clients, r.info)
+	}
+	if end < len(rows) {
+		last := rows[end-1]
+		page.NextCursor = encodeCursor(q, last.sortValue, last.info.CID)
+	}
+	return page, nil
+}
+
+// StreamClients returns every client matching the query in its sort order
+func (s *MemoryStore) StreamClients(ctx context.Context, f Filter, q ClientQuery) (ClientRows, error) {
+	rows, err := s.matchingClients(f, q)
+	if err != nil {
+		return nil, err
+	}
+	return &sliceClientRows{rows: rows}, nil
+}
+
+// matchingClients returns the sorted report rows for the filter, member status and search
+func (s *MemoryStore) matchingClients(f Filter, q ClientQuery) ([]clientRow, error) {
+	if _, ok := ClientSortKeys[q.Sort]; !ok {
+		return nil, fmt.Errorf("store: unknown sort key %q", q.Sort)
+	}
+
+	customers, err := s.filterCustomers(f)
+	if err != nil {
+		return nil, err
+	}
+
+	search := strings.ToLower(q.Search)
+	var rows []clientRow
+	for _, c := range customers {
+		if c.MemberStatus != q.MemberStatus {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(c.CustomerName), search) && !strings.Contains(strings.ToLower(c.TID), search) {
+			continue
+		}
+		rows = append(rows, clientRow{
+			info: ActiveClientInfo{
+				UnitName:       c.UnitName,
+				CenterName:     c.CenterName,
+				CID:            c.TID,
+				ClientName:     c.CustomerName,
+				DateRecognized: c.DateRecognized.Format("Jan. 02, 2006"),
+				MemberStatus:   c.MemberStatus,
+			},
+			sortValue: clientSortValue(c, q.Sort),
+		})
+	}
+	sortClients(rows, q.Desc)
+	return rows, nil
+}
+
+// clientSortValue returns the value a customer is ordered by for a sort key
+func clientSortValue(c Customer, key string) string {
+	switch key {
+	case "unit_name":
+		return c.UnitName
+	case "center_name":
+		return c.CenterName
+	case "cid":
+		return c.TID
+	case "client_name":
+		return c.CustomerName
+	case "date_recognized":
+		return c.DateRecognized.Format(dateLayout)
+	case "member_status":
+		return c.MemberStatus
+	}
+	return ""
+}
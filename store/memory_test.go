@@ -0,0 +1,294 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"rbi_backend/timeseries"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// seed returns a store over two officers' clients and loans, most of them
+// recognized or opened in March 2024, with ages taken at the end of June 2024
+func seed() *MemoryStore {
+	s := NewMemoryStore([]Customer{
+		{TID: "C1", CustomerName: "Ana Cruz", AccountOfficer: "ao1", UnitName: "U1", CenterName: "CA", MemberStatus: "Active", DateRecognized: date("2024-03-04"), DateOfBirth: date("1990-06-01")},
+		{TID: "C2", CustomerName: "Ben Diaz", AccountOfficer: "ao1", UnitName: "U1", CenterName: "CA", MemberStatus: "Active", DateRecognized: date("2024-03-12"), DateOfBirth: date("2000-07-01")},
+		{TID: "C3", CustomerName: "Cora Reyes", AccountOfficer: "ao1", UnitName: "U1", CenterName: "CB", MemberStatus: "Resigned", DateRecognized: date("2024-03-20"), DateOfBirth: date("1950-01-01")},
+		{TID: "C4", CustomerName: "Dan Lim", AccountOfficer: "ao2", UnitName: "U2", CenterName: "CC", MemberStatus: "Active", DateRecognized: date("2024-03-05"), DateOfBirth: date("1940-01-01")},
+		{TID: "C5", CustomerName: "Eve Tan", AccountOfficer: "ao2", UnitName: "U2", CenterName: "CC", MemberStatus: "Active", DateRecognized: date("2024-02-28"), DateOfBirth: date("1985-01-01")},
+	}, []LoanAccount{
+		{Customer: "C1", AccountOfficer: "ao1", BillType: "Regular", BillStatus: "DUE", AccountTitle: "Micro Loan", OpeningDate: date("2024-03-04"), OnlineActualBal: -1000},
+		{Customer: "C2", AccountOfficer: "ao1", BillType: "Regular", BillStatus: "PAID", AccountTitle: "Micro Loan", OpeningDate: date("2024-03-15"), OnlineActualBal: -500},
+		{Customer: "C4", AccountOfficer: "ao2", BillType: "Special", BillStatus: "PAID", AccountTitle: "Housing", OpeningDate: date("2024-03-06"), OnlineActualBal: -2000},
+		{Customer: "C1", AccountOfficer: "ao1", OpeningDate: date("2024-03-10"), OnlineActualBal: -300},
+		{Customer: "C5", AccountOfficer: "ao2", BillType: "Regular", BillStatus: "DUE", AccountTitle: "Micro Loan", OpeningDate: date("2024-02-20"), OnlineActualBal: -700},
+	})
+	s.now = func() time.Time { return date("2024-06-30") }
+	return s
+}
+
+// march returns a filter over March 2024 narrowed by narrow
+func march(narrow func(*Filter)) Filter {
+	f := Filter{AllOfficers: true, StartDate: "2024-03-01", EndDate: "2024-03-31"}
+	if narrow != nil {
+		narrow(&f)
+	}
+	return f
+}
+
+var (
+	ao1     = func(f *Filter) { f.AllOfficers, f.Officers = false, []string{"ao1"} }
+	ao2     = func(f *Filter) { f.AllOfficers, f.Officers = false, []string{"ao2"} }
+	unitU1  = func(f *Filter) { f.Units = []string{"U1"} }
+	unitU2  = func(f *Filter) { f.Units = []string{"U2"} }
+	centerB = func(f *Filter) { f.Centers = []string{"CB"} }
+)
+
+func TestCountsByStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Filter
+		want []Result
+	}{
+		{"every officer", march(nil), []Result{{"Active", 3}, {"Resigned", 1}, {"Total Client", 4}}},
+		{"one officer", march(ao1), []Result{{"Active", 2}, {"Resigned", 1}, {"Total Client", 3}}},
+		{"unit", march(unitU2), []Result{{"Active", 1}, {"Total Client", 1}}},
+		{"center", march(centerB), []Result{{"Resigned", 1}, {"Total Client", 1}}},
+		{"nothing in range", Filter{AllOfficers: true, StartDate: "2023-01-01", EndDate: "2023-12-31"}, []Result{{"Total Client", 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := seed().CountsByStatus(context.Background(), tt.f)
+			if err != nil {
+				t.Fatalf("CountsByStatus: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CountsByStatus(%+v) = %v, want %v", tt.f, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoanTotalsByBillType(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Filter
+		want []LoanAccountResult
+	}{
+		{"every officer", march(nil), []LoanAccountResult{{"", 0, 300}, {"Regular", 2, 1500}, {"Special", 1, 2000}}},
+		{"one officer", march(ao2), []LoanAccountResult{{"Special", 1, 2000}}},
+		{"unit through the customer", march(unitU1), []LoanAccountResult{{"", 0, 300}, {"Regular", 2, 1500}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := seed().LoanTotalsByBillType(context.Background(), tt.f)
+			if err != nil {
+				t.Fatalf("LoanTotalsByBillType: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoanTotalsByBillType(%+v) = %v, want %v", tt.f, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapitalBuildUp(t *testing.T) {
+	got, err := seed().CapitalBuildUp(context.Background(), march(nil))
+	if err != nil {
+		t.Fatalf("CapitalBuildUp: %v", err)
+	}
+	if want := []CapitalBuildUpResult{{"Capital Build Up", 3500}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CapitalBuildUp = %v, want %v", got, want)
+	}
+
+	if _, err := seed().CapitalBuildUp(context.Background(), march(unitU1)); !errors.Is(err, ErrUnsupportedFilter) {
+		t.Errorf("CapitalBuildUp with a unit = %v, want ErrUnsupportedFilter", err)
+	}
+}
+
+func TestAgeGroups(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Filter
+		want AgeGroupCount
+	}{
+		{"every officer", march(nil), AgeGroupCount{Age18_29: 1, Age30_39: 1, Age70_79: 1, Age80Plus: 1, Total: 4}},
+		// C2 turns 24 on 2024-07-01, the day after now
+		{"birthday not yet reached", march(func(f *Filter) { ao1(f); f.Centers = []string{"CA"} }), AgeGroupCount{Age18_29: 1, Age30_39: 1, Total: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := seed().AgeGroups(context.Background(), tt.f)
+			if err != nil {
+				t.Fatalf("AgeGroups: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AgeGroups(%+v) = %+v, want %+v", tt.f, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductCounts(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Filter
+		want []ProductCount
+	}{
+		{"every officer", march(nil), []ProductCount{{"Housing", 1}, {"Micro Loan", 2}}},
+		{"one officer", march(ao1), []ProductCount{{"Micro Loan", 2}}},
+		{"nothing in range", Filter{AllOfficers: true, StartDate: "2023-01-01", EndDate: "2023-12-31"}, []ProductCount{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := seed().ProductCounts(context.Background(), tt.f)
+			if err != nil {
+				t.Fatalf("ProductCounts: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductCounts(%+v) = %v, want %v", tt.f, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCenterSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Filter
+		want []CenterSummary
+	}{
+		{"every officer", march(nil), []CenterSummary{
+			{CenterName: "CA", NoOfClients: 2, WithLoans: 2, PastDue: 1},
+			{CenterName: "CB", NoOfClients: 1, WithoutLoans: 1},
+			{CenterName: "CC", NoOfClients: 1, WithLoans: 1},
+			{CenterName: "Total Centers", NoOfClients: 4, WithLoans: 3, WithoutLoans: 1, PastDue: 1},
+		}},
+		{"unit", march(unitU2), []CenterSummary{
+			{CenterName: "CC", NoOfClients: 1, WithLoans: 1},
+			{CenterName: "Total Centers", NoOfClients: 1, WithLoans: 1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := seed().CenterSummary(context.Background(), tt.f)
+			if err != nil {
+				t.Fatalf("CenterSummary: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CenterSummary(%+v) = %+v, want %+v", tt.f, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeeklyCounts(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Filter
+		b    timeseries.Bucketing
+		want []WeeklyCount
+	}{
+		{"months", march(ao1), timeseries.Bucketing{Granularity: timeseries.Month}, []WeeklyCount{
+			{Particulars: "Active", Week: "2024-03", PeriodStart: "2024-03-01", PeriodEnd: "2024-03-31", Count: 2},
+			{Particulars: "Resigned", Week: "2024-03", PeriodStart: "2024-03-01", PeriodEnd: "2024-03-31", Count: 1},
+			{Particulars: "Total Client", Week: "2024-03", PeriodStart: "2024-03-01", PeriodEnd: "2024-03-31", Count: 3},
+		}},
+		{"weeks of the month without dates", Filter{Officers: []string{"ao1"}, StartDate: "2024-03-01", EndDate: "2024-03-14"}, timeseries.Default, []WeeklyCount{
+			{Particulars: "Active", Week: "Week 1", Count: 1},
+			{Particulars: "Active", Week: "Week 2", Count: 1},
+			{Particulars: "Total Client", Week: "Week 1", Count: 1},
+			{Particulars: "Total Client", Week: "Week 2", Count: 1},
+		}},
+		{"zero-filled total", Filter{Officers: []string{"ao2"}, StartDate: "2024-04-01", EndDate: "2024-04-30"}, timeseries.Bucketing{Granularity: timeseries.Month}, []WeeklyCount{
+			{Particulars: "Total Client", Week: "2024-04", PeriodStart: "2024-04-01", PeriodEnd: "2024-04-30", Count: 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := seed().WeeklyCounts(context.Background(), tt.f, tt.b)
+			if err != nil {
+				t.Fatalf("WeeklyCounts: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WeeklyCounts(%+v) = %+v, want %+v", tt.f, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeeklyCapital(t *testing.T) {
+	got, err := seed().WeeklyCapital(context.Background(), march(ao1), timeseries.Default)
+	if err != nil {
+		t.Fatalf("WeeklyCapital: %v", err)
+	}
+	// The unbilled loan opened on 2024-03-10 is left out
+	want := []WeeklyCapitalBuildUp{
+		{Title: "Capital Build Up", Week: "Week 1", TotalCapital: 1000},
+		{Title: "Capital Build Up", Week: "Week 2", TotalCapital: 0},
+		{Title: "Capital Build Up", Week: "Week 3", TotalCapital: 500},
+		{Title: "Capital Build Up", Week: "Week 4", TotalCapital: 0},
+		{Title: "Capital Build Up", Week: "Week 5", TotalCapital: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WeeklyCapital = %+v, want %+v", got, want)
+	}
+}
+
+func TestListClients(t *testing.T) {
+	tests := []struct {
+		name  string
+		f     Filter
+		q     ClientQuery
+		want  []string
+		total int
+	}{
+		{"sorted by name", march(nil), ClientQuery{MemberStatus: "Active", Sort: "client_name", Limit: 10}, []string{"C1", "C2", "C4"}, 3},
+		{"descending", march(nil), ClientQuery{MemberStatus: "Active", Sort: "client_name", Desc: true, Limit: 10}, []string{"C4", "C2", "C1"}, 3},
+		{"member status", march(nil), ClientQuery{MemberStatus: "Resigned", Sort: "cid", Limit: 10}, []string{"C3"}, 1},
+		{"search by name", march(nil), ClientQuery{MemberStatus: "Active", Search: "DIA", Sort: "cid", Limit: 10}, []string{"C2"}, 1},
+		{"search by CID", march(nil), ClientQuery{MemberStatus: "Active", Search: "c4", Sort: "cid", Limit: 10}, []string{"C4"}, 1},
+		{"offset and limit", march(nil), ClientQuery{MemberStatus: "Active", Sort: "cid", Limit: 1, Offset: 1}, []string{"C2"}, 3},
+		{"offset past the end", march(nil), ClientQuery{MemberStatus: "Active", Sort: "cid", Limit: 10, Offset: 5}, nil, 3},
+		{"unit", march(unitU1), ClientQuery{MemberStatus: "Active", Sort: "cid", Limit: 10}, []string{"C1", "C2"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := seed().ListClients(context.Background(), tt.f, tt.q)
+			if err != nil {
+				t.Fatalf("ListClients: %v", err)
+			}
+			var got []string
+			for _, c := range page.Clients {
+				got = append(got, c.CID)
+			}
+			if !reflect.DeepEqual(got, tt.want) || page.Total != tt.total {
+				t.Errorf("ListClients(%+v) = %v of %d, want %v of %d", tt.q, got, page.Total, tt.want, tt.total)
+			}
+		})
+	}
+
+	page, err := seed().ListClients(context.Background(), march(nil), ClientQuery{MemberStatus: "Resigned", Sort: "cid", Limit: 10})
+	if err != nil {
+		t.Fatalf("ListClients: %v", err)
+	}
+	want := ActiveClientInfo{UnitName: "U1", CenterName: "CB", CID: "C3", ClientName: "Cora Reyes", DateRecognized: "Mar. 20, 2024", MemberStatus: "Resigned"}
+	if len(page.Clients) != 1 || page.Clients[0] != want || page.NextCursor != "" {
+		t.Errorf("ListClients(Resigned) = %+v, cursor %q, want [%+v] and no cursor", page.Clients, page.NextCursor, want)
+	}
+
+	if _, err := seed().ListClients(context.Background(), march(nil), ClientQuery{Sort: "balance", Limit: 10}); err == nil {
+		t.Error("ListClients accepted an unknown sort key")
+	}
+}
@@ -0,0 +1,83 @@
+package store
+
+// Result represents the output format
+type Result struct {
+	Particulars string `json:"particulars"`
+	Count       int    `json:"count"`
+}
+
+// LoanAccountResult represents the output format for the loan account query
+type LoanAccountResult struct {
+	Particulars string  `json:"particulars"`
+	Count       int     `json:"count"`
+	Amount      float64 `json:"amount"`
+}
+
+// CapitalBuildUpResult represents the output format for the capital build-up query
+type CapitalBuildUpResult struct {
+	Title        string  `json:"title"`
+	TotalCapital float64 `json:"total_capital"`
+}
+
+// AgeGroupCount represents the output format for the age group counts query
+type AgeGroupCount struct {
+	Age18_29  int `json:"age_18_29"`
+	Age30_39  int `json:"age_30_39"`
+	Age40_49  int `json:"age_40_49"`
+	Age50_59  int `json:"age_50_59"`
+	Age60_69  int `json:"age_60_69"`
+	Age70_79  int `json:"age_70_79"`
+	Age80Plus int `json:"age_80_plus"`
+	Total     int `json:"total"`
+}
+
+// ProductCount represents the output format for the product count query
+type ProductCount struct {
+	ProductName string `json:"product_name"`
+	Count       int    `json:"count"`
+}
+
+// CenterSummary represents the output format for the center summary query
+type CenterSummary struct {
+	CenterName   string `json:"center_name"`
+	NoOfClients  int    `json:"no_of_clients"`
+	WithLoans    int    `json:"with_loans"`
+	WithoutLoans int    `json:"without_loans"`
+	PastDue      int    `json:"past_due"`
+}
+
+// WeeklyCount represents the output format for the weekly customer count query
+type WeeklyCount struct {
+	Particulars string `json:"particulars"`
+	// Week labels the period: Week 1 to Week 5 of the month by default, or
+	// such as 2024-W18, 2024-05 or 2024-Q2 for a requested granularity
+	Week string `json:"week"`
+	// PeriodStart and PeriodEnd are the first and last day of the period,
+	// only set for a requested granularity
+	PeriodStart string `json:"period_start,omitempty"`
+	PeriodEnd   string `json:"period_end,omitempty"`
+	Count       int    `json:"count"`
+}
+
+// WeeklyCapitalBuildUp represents the output format for the weekly capital build-up query
+type WeeklyCapitalBuildUp struct {
+	Title string `json:"title"`
+	// Week labels the period: Week 1 to Week 5 of the month by default, or
+	// such as 2024-W18, 2024-05 or 2024-Q2 for a requested granularity
+	Week string `json:"week"`
+	// PeriodStart and PeriodEnd are the first and last day of the period,
+	// only set for a requested granularity
+	PeriodStart  string  `json:"period_start,omitempty"`
+	PeriodEnd    string  `json:"period_end,omitempty"`
+	TotalCapital float64 `json:"total_capital"`
+}
+
+// ActiveClientInfo represents the output format for the active client query
+type ActiveClientInfo struct {
+	UnitName       string `json:"unit_name"`
+	CenterName     string `json:"center_name"`
+	CID            string `json:"cid"`
+	ClientName     string `json:"client_name"`
+	DateRecognized string `json:"date_recognized"`
+	MemberStatus   string `json:"member_status"`
+}
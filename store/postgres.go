@@ -0,0 +1,513 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"rbi_backend/timeseries"
+
+	"gorm.io/gorm"
+)
+
+// PostgresStore implements AODashboardStore on top of the rbi_streamingdb tables
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore returns a store that runs its queries through db
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// CountsByStatus returns the client count per member status plus a "Total Client" row
+func (s *PostgresStore) CountsByStatus(ctx context.Context, f Filter) ([]Result, error) {
+	var results []Result
+
+	cond, args := customerCondition(f)
+
+	query := fmt.Sprintf(`
+		SELECT 
+			ci.member_status AS "Particulars",
+			count(ci.t_id) AS "Count"
+		FROM 
+			public.customer_info ci 
+		WHERE 
+			%[1]s
+		GROUP BY 
+			ci.member_status 
+		UNION ALL
+		SELECT 
+			'Total Client' AS "Particulars",
+			Count(*) AS "Count"
+		FROM 
+			public.customer_info ci 
+		WHERE 
+			%[1]s
+	`, cond)
+
+	err := s.db.WithContext(ctx).Raw(query, repeat(args, 2)...).Scan(&results).Error
+	return results, err
+}
+
+// LoanTotalsByBillType returns the loan count and amount per bill type
+func (s *PostgresStore) LoanTotalsByBillType(ctx context.Context, f Filter) ([]LoanAccountResult, error) {
+	var results []LoanAccountResult
+
+	cond, args := loanCondition(f)
+
+	query := fmt.Sprintf(`
+		SELECT 
+			la.bill_type AS "Particulars",
+			count(la.bill_type) AS "Count",
+			sum(la.online_actual_bal::numeric) * -1 AS "Amount"
+		FROM 
+			public.loan_acct la
+		WHERE 
+			%[1]s
+		GROUP BY 
+			la.bill_type
+	`, cond)
+
+	err := s.db.WithContext(ctx).Raw(query, args...).Scan(&results).Error
+	return results, err
+}
+
+// CapitalBuildUp returns the totals computed by the get_capital_build_up database
+// function, summed per title when the filter covers several officers. The
+// function takes a single officer, so one query applies it to each officer of
+// the filter in turn.
+func (s *PostgresStore) CapitalBuildUp(ctx context.Context, f Filter) ([]CapitalBuildUpResult, error) {
+	if len(f.Units) > 0 || len(f.Centers) > 0 {
+		return nil, ErrUnsupportedFilter
+	}
+
+	// officers lists each officer with their position, which orders the rows
+	// as running the function per officer in turn would
+	officers := `
+			SELECT o.account_officer AS officer, row_number() OVER (ORDER BY o.account_officer) AS n
+			FROM (SELECT DISTINCT la.account_officer FROM public.loan_acct la WHERE la.account_officer IS NOT NULL) o`
+	var args []any
+	if !f.AllOfficers {
+		if len(f.Officers) == 0 {
+			return nil, nil
+		}
+		values := make([]string, len(f.Officers))
+		for i, officer := range f.Officers {
+			values[i] = fmt.Sprintf("(?, %d)", i+1)
+			args = append(args, officer)
+		}
+		officers = "VALUES " + strings.Join(values, ", ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT 
+			cb.*
+		FROM 
+			(%s) AS ao (officer, n)
+		CROSS JOIN LATERAL 
+			get_capital_build_up(ao.officer, ?, ?) WITH ORDINALITY AS cb
+		ORDER BY 
+			ao.n, cb.ordinality
+	`, officers)
+
+	var rows []CapitalBuildUpResult
+	if err := s.db.WithContext(ctx).Raw(query, append(args, f.StartDate, f.EndDate)...).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var results []CapitalBuildUpResult
+	index := map[string]int{}
+	for _, row := range rows {
+		if i, ok := index[row.Title]; ok {
+			results[i].TotalCapital += row.TotalCapital
+			continue
+		}
+		index[row.Title] = len(results)
+		results = append(results, row)
+	}
+
+	return results, nil
+}
+
+// Officers returns every account officer with loan accounts, sorted by name
+func (s *PostgresStore) Officers(ctx context.Context) ([]string, error) {
+	var officers []string
+	err := s.db.WithContext(ctx).Raw(`SELECT DISTINCT la.account_officer FROM public.loan_acct la WHERE la.account_officer IS NOT NULL ORDER BY la.account_officer`).Scan(&officers).Error
+	return officers, err
+}
+
+// AgeGroups returns the client count per age bracket
+func (s *PostgresStore) AgeGroups(ctx context.Context, f Filter) (AgeGroupCount, error) {
+	var result AgeGroupCount
+
+	cond, args := customerCondition(f)
+
+	query := fmt.Sprintf(`
+		SELECT 
+			COUNT(CASE WHEN EXTRACT(YEAR FROM AGE(ci.date_of_birth)) BETWEEN 18 AND 29 THEN 1 END) AS "Age 18-29",
+			COUNT(CASE WHEN EXTRACT(YEAR FROM AGE(ci.date_of_birth)) BETWEEN 30 AND 39 THEN 1 END) AS "Age 30-39",
+			COUNT(CASE WHEN EXTRACT(YEAR FROM AGE(ci.date_of_birth)) BETWEEN 40 AND 49 THEN 1 END) AS "Age 40-49",
+			COUNT(CASE WHEN EXTRACT(YEAR FROM AGE(ci.date_of_birth)) BETWEEN 50 AND 59 THEN 1 END) AS "Age 50-59",
+			COUNT(CASE WHEN EXTRACT(YEAR FROM AGE(ci.date_of_birth)) BETWEEN 60 AND 69 THEN 1 END) AS "Age 60-69",
+			COUNT(CASE WHEN EXTRACT(YEAR FROM AGE(ci.date_of_birth)) BETWEEN 70 AND 79 THEN 1 END) AS "Age 70-79",
+			COUNT(CASE WHEN EXTRACT(YEAR FROM AGE(ci.date_of_birth)) >= 80 THEN 1 END) AS "Age 80+",
+			COUNT(*) AS "TOTAL"
+		FROM 
+			public.customer_info ci 
+		WHERE 
+			%[1]s
+	`, cond)
+
+	row := s.db.WithContext(ctx).Raw(query, args...).Row()
+	err := row.Scan(&result.Age18_29, &result.Age30_39, &result.Age40_49, &result.Age50_59, &result.Age60_69, &result.Age70_79, &result.Age80Plus, &result.Total)
+	return result, err
+}
+
+// ProductCounts returns the loan count per product
+func (s *PostgresStore) ProductCounts(ctx context.Context, f Filter) ([]ProductCount, error) {
+	var results []ProductCount
+
+	cond, args := loanCondition(f)
+
+	query := fmt.Sprintf(`
+		SELECT 
+			account_title_1 AS "Product Name",
+			COUNT(account_title_1) AS "Count"
+		FROM 
+			public.loan_acct la 
+		WHERE 
+			%[1]s
+		GROUP BY 
+			account_title_1
+	`, cond)
+
+	rows, err := s.db.WithContext(ctx).Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productCount ProductCount
+		if err := rows.Scan(&productCount.ProductName, &productCount.Count); err != nil {
+			return nil, err
+		}
+		results = append(results, productCount)
+	}
+
+	return results, rows.Err()
+}
+
+// CenterSummary returns the client summary per center plus a "Total Centers" row
+func (s *PostgresStore) CenterSummary(ctx context.Context, f Filter) ([]CenterSummary, error) {
+	var results []CenterSummary
+
+	cond, args := customerCondition(f)
+
+	query := fmt.Sprintf(`
+		SELECT 
+			ci.center_name AS "Center Name",
+			COUNT(DISTINCT ci.t_id) AS "No of Clients",
+			COUNT(DISTINCT CASE WHEN la.customer IS NOT NULL THEN ci.t_id END) AS "w/ Loans",
+			COUNT(DISTINCT CASE WHEN la.customer IS NULL THEN ci.t_id END) AS "w/o Loans",
+			COUNT(DISTINCT CASE WHEN la.bill_status = 'DUE' THEN ci.t_id END) AS "Past Due"
+		FROM 
+			public.customer_info ci 
+		LEFT JOIN 
+			public.loan_acct la ON ci.t_id = la.customer 
+		WHERE 
+			%[1]s
+		GROUP BY 
+			ci.center_name
+		UNION ALL  
+		SELECT
+			'Total Centers' AS "Center Name",
+			COUNT(DISTINCT ci.t_id) AS "No of Clients",
+			COUNT(DISTINCT CASE WHEN la.customer IS NOT NULL THEN ci.t_id END) AS "w/ Loans",
+			COUNT(DISTINCT CASE WHEN la.customer IS NULL THEN ci.t_id END) AS "w/o Loans",
+			COUNT(DISTINCT CASE WHEN la.bill_status = 'DUE' THEN ci.t_id END) AS "Past Due"
+		FROM 
+			public.customer_info ci 
+		LEFT JOIN 
+			public.loan_acct la ON ci.t_id = la.customer 
+		WHERE 
+			%[1]s
+	`, cond)
+
+	rows, err := s.db.WithContext(ctx).Raw(query, repeat(args, 2)...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var centerSummary CenterSummary
+		if err := rows.Scan(&centerSummary.CenterName, &centerSummary.NoOfClients, &centerSummary.WithLoans, &centerSummary.WithoutLoans, &centerSummary.PastDue); err != nil {
+			return nil, err
+		}
+		results = append(results, centerSummary)
+	}
+
+	return results, rows.Err()
+}
+
+// WeeklyCounts returns the client count per member status, plus a "Total
+// Client" row, for every period of b overlapping the filter's date range. The
+// query counts per day; the days are bucketed into periods in Go so every
+// granularity and week start share one query.
+func (s *PostgresStore) WeeklyCounts(ctx context.Context, f Filter, b timeseries.Bucketing) ([]WeeklyCount, error) {
+	series, err := newSeries[int](f, b)
+	if err != nil {
+		return nil, err
+	}
+
+	cond, args := customerCondition(f)
+
+	query := fmt.Sprintf(`
+		SELECT 
+			ci.member_status AS "Particulars",
+			ci.l_date_recog::date AS "Day",
+			COUNT(ci.t_id) AS "Count"
+		FROM 
+			public.customer_info ci 
+		WHERE 
+			%s
+		GROUP BY 
+			ci.member_status, "Day"
+	`, cond)
+
+	rows, err := s.db.WithContext(ctx).Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			particulars string
+			day         time.Time
+			count       int
+		)
+		if err := rows.Scan(&particulars, &day, &count); err != nil {
+			return nil, err
+		}
+		series.Add(particulars, day, count)
+		series.Add(totalClient, day, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return weeklyCounts(series), nil
+}
+
+// WeeklyCapital returns the capital build-up for every period of b
+// overlapping the filter's date range, summed per day by the query
+func (s *PostgresStore) WeeklyCapital(ctx context.Context, f Filter, b timeseries.Bucketing) ([]WeeklyCapitalBuildUp, error) {
+	series, err := newSeries[float64](f, b)
+	if err != nil {
+		return nil, err
+	}
+
+	cond, args := loanCondition(f)
+
+	query := fmt.Sprintf(`
+		SELECT 
+			la.opening_date::date AS "Day",
+			SUM(la.online_actual_bal::NUMERIC) * -1 AS "Total Capital"
+		FROM 
+			public.loan_acct la 
+		WHERE 
+			%s
+			AND la.bill_type IS NOT NULL
+		GROUP BY 
+			"Day"
+	`, cond)
+
+	rows, err := s.db.WithContext(ctx).Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			day   time.Time
+			total float64
+		)
+		if err := rows.Scan(&day, &total); err != nil {
+			return nil, err
+		}
+		series.Add(capitalBuildUp, day, total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return weeklyCapital(series), nil
+}
+
+// ListClients returns one sorted, searched page of the clients with the query's member status
+func (s *PostgresStore) ListClients(ctx context.Context, f Filter, q ClientQuery) (ClientPage, error) {
+	var page ClientPage
+
+	sortExpr, ok := ClientSortKeys[q.Sort]
+	if !ok {
+		return page, fmt.Errorf("store: unknown sort key %q", q.Sort)
+	}
+	cursor, err := decodeCursor(q)
+	if err != nil {
+		return page, err
+	}
+
+	filter := clientCondition(f, q)
+	where, whereArgs := filter.build()
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM public.customer_info ci WHERE %s`, where)
+	if err := s.db.WithContext(ctx).Raw(countQuery, whereArgs...).Scan(&page.Total).Error; err != nil {
+		return page, err
+	}
+
+	direction, compare := "ASC", ">"
+	if q.Desc {
+		direction, compare = "DESC", "<"
+	}
+	if cursor != nil {
+		filter.add(fmt.Sprintf("(%s, ci.t_id::text) %s (?, ?)", sortExpr, compare), cursor.Value, cursor.CID)
+		where, whereArgs = filter.build()
+	}
+
+	query := fmt.Sprintf(`
+		SELECT 
+			ci.unit_name AS "Unit Name",
+			ci.center_name AS "Center Name",
+			ci.t_id AS "CID",
+			ci.customer_name AS "Client Name",
+			to_char(ci.l_date_recog, 'Mon. DD, YYYY') AS "Date Recognized",
+			ci.member_status AS "Member Status",
+			(%[2]s)::text AS "Sort Value"
+		FROM 
+			public.customer_info ci 
+		WHERE 
+			%[1]s
+		ORDER BY 
+			%[2]s %[3]s, ci.t_id::text %[3]s
+		LIMIT ?
+	`, where, sortExpr, direction)
+
+	// Fetch one extra row to tell whether another page follows
+	queryArgs := append(whereArgs, q.Limit+1)
+	if cursor == nil {
+		query += " OFFSET ?"
+		queryArgs = append(queryArgs, q.Offset)
+	}
+
+	rows, err := s.db.WithContext(ctx).Raw(query, queryArgs...).Rows()
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	var lastSortValue string
+	for rows.Next() {
+		var clientInfo ActiveClientInfo
+		var unit, center, name, date, status sql.NullString
+		var sortValue string
+		if err := rows.Scan(&unit, &center, &clientInfo.CID, &name, &date, &status, &sortValue); err != nil {
+			return page, err
+		}
+		clientInfo.UnitName, clientInfo.CenterName, clientInfo.ClientName, clientInfo.DateRecognized, clientInfo.MemberStatus = unit.String, center.String, name.String, date.String, status.String
+		if len(page.Clients) == q.Limit {
+			page.NextCursor = encodeCursor(q, lastSortValue, page.Clients[len(page.Clients)-1].CID)
+			break
+		}
+		page.Clients = append(page.Clients, clientInfo)
+		lastSortValue = sortValue
+	}
+
+	return page, rows.Err()
+}
+
+// StreamClients returns every client matching the query in its sort order,
+// reading rows from the database cursor as the caller advances
+func (s *PostgresStore) StreamClients(ctx context.Context, f Filter, q ClientQuery) (ClientRows, error) {
+	sortExpr, ok := ClientSortKeys[q.Sort]
+	if !ok {
+		return nil, fmt.Errorf("store: unknown sort key %q", q.Sort)
+	}
+
+	filter := clientCondition(f, q)
+	where, whereArgs := filter.build()
+	direction := "ASC"
+	if q.Desc {
+		direction = "DESC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT 
+			ci.unit_name AS "Unit Name",
+			ci.center_name AS "Center Name",
+			ci.t_id AS "CID",
+			ci.customer_name AS "Client Name",
+			to_char(ci.l_date_recog, 'Mon. DD, YYYY') AS "Date Recognized",
+			ci.member_status AS "Member Status"
+		FROM 
+			public.customer_info ci 
+		WHERE 
+			%[1]s
+		ORDER BY 
+			%[2]s %[3]s, ci.t_id::text %[3]s
+	`, where, sortExpr, direction)
+
+	rows, err := s.db.WithContext(ctx).Raw(query, whereArgs...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	return &sqlClientRows{rows: rows}, nil
+}
+
+// clientCondition is the customer filter plus the client query's member status and search
+func clientCondition(f Filter, q ClientQuery) condition {
+	cond, args := customerCondition(f)
+	filter := condition{clauses: []string{cond}, args: args}
+	filter.add("ci.member_status = ?", q.MemberStatus)
+	if q.Search != "" {
+		pattern := "%" + escapeLike(q.Search) + "%"
+		filter.add("(ci.customer_name ILIKE ? OR ci.t_id::text ILIKE ?)", pattern, pattern)
+	}
+	return filter
+}
+
+// sqlClientRows is a ClientRows over an open database cursor
+type sqlClientRows struct {
+	rows   *sql.Rows
+	client ActiveClientInfo
+	err    error
+}
+
+func (r *sqlClientRows) Next() bool {
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+	c := &r.client
+	var unit, center, name, date, status sql.NullString
+	if r.err = r.rows.Scan(&unit, &center, &c.CID, &name, &date, &status); r.err != nil {
+		return false
+	}
+	c.UnitName, c.CenterName, c.ClientName, c.DateRecognized, c.MemberStatus = unit.String, center.String, name.String, date.String, status.String
+	return true
+}
+
+func (r *sqlClientRows) Client() ActiveClientInfo { return r.client }
+
+func (r *sqlClientRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+func (r *sqlClientRows) Close() error { return r.rows.Close() }
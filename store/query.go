@@ -0,0 +1,101 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// condition accumulates SQL predicates joined with AND, together with their
+// positional arguments, so every dashboard query filters the same way
+type condition struct {
+	clauses []string
+	args    []any
+}
+
+// add appends a predicate and the arguments for its placeholders
+func (c *condition) add(clause string, args ...any) {
+	c.clauses = append(c.clauses, clause)
+	c.args = append(c.args, args...)
+}
+
+// build returns the predicate text and its arguments
+func (c *condition) build() (string, []any) {
+	if len(c.clauses) == 0 {
+		return "TRUE", nil
+	}
+	return strings.Join(c.clauses, "\n\t\t\tAND "), c.args
+}
+
+// addOfficers restricts the column to the filter's officers unless it covers all of them
+func (c *condition) addOfficers(f Filter, column string) {
+	if !f.AllOfficers {
+		c.add(column+" IN ?", f.Officers)
+	}
+}
+
+// customerCondition builds the WHERE condition for queries over
+// public.customer_info aliased as ci
+func customerCondition(f Filter) (string, []any) {
+	var c condition
+	c.addOfficers(f, "ci.account_officer")
+	c.add("ci.l_date_recog BETWEEN ? AND ?", f.StartDate, f.EndDate)
+	if len(f.Units) > 0 {
+		c.add("ci.unit_name IN ?", f.Units)
+	}
+	if len(f.Centers) > 0 {
+		c.add("ci.center_name IN ?", f.Centers)
+	}
+	return c.build()
+}
+
+// loanCondition builds the WHERE condition for queries over public.loan_acct
+// aliased as la; unit and center filters go through the owning customer
+func loanCondition(f Filter) (string, []any) {
+	var c condition
+	c.addOfficers(f, "la.account_officer")
+	c.add("la.opening_date::date BETWEEN ? AND ?", f.StartDate, f.EndDate)
+	if len(f.Units) > 0 || len(f.Centers) > 0 {
+		var sub condition
+		if len(f.Units) > 0 {
+			sub.add("cu.unit_name IN ?", f.Units)
+		}
+		if len(f.Centers) > 0 {
+			sub.add("cu.center_name IN ?", f.Centers)
+		}
+		subCond, subArgs := sub.build()
+		c.add("la.customer IN (SELECT cu.t_id FROM public.customer_info cu WHERE "+subCond+")", subArgs...)
+	}
+	return c.build()
+}
+
+// repeat concatenates args n times, for queries that reuse the same condition
+func repeat(args []any, n int) []any {
+	out := make([]any, 0, len(args)*n)
+	for i := 0; i < n; i++ {
+		out = append(out, args...)
+	}
+	return out
+}
+
+// Source names the table a query reads from, which decides how a Filter applies
+type Source string
+
+const (
+	// SourceCustomers is public.customer_info aliased as ci
+	SourceCustomers Source = "customers"
+	// SourceLoans is public.loan_acct aliased as la
+	SourceLoans Source = "loans"
+)
+
+// Condition returns the WHERE condition applying f to the given source
+func Condition(src Source, f Filter) (string, []any, error) {
+	switch src {
+	case SourceCustomers:
+		cond, args := customerCondition(f)
+		return cond, args, nil
+	case SourceLoans:
+		cond, args := loanCondition(f)
+		return cond, args, nil
+	}
+	return "", nil, fmt.Errorf("store: unknown source %q", src)
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"rbi_backend/timeseries"
+)
+
+// totalClient is the particulars of the row summing every member status
+const totalClient = "Total Client"
+
+// capitalBuildUp is the title of the capital build-up rows
+const capitalBuildUp = "Capital Build Up"
+
+// newSeries returns an empty series over the periods of b covering the
+// filter's date range
+func newSeries[V timeseries.Number](f Filter, b timeseries.Bucketing) (*timeseries.Series[V], error) {
+	start, end, err := dateRange(f)
+	if err != nil {
+		return nil, err
+	}
+	return timeseries.NewSeries[V](b, start, end), nil
+}
+
+// weeklyCounts lays out the client counts per member status, plus the total,
+// as one row per period, ordered by particulars and period
+func weeklyCounts(s *timeseries.Series[int]) []WeeklyCount {
+	s.Ensure(totalClient)
+	keys, periods := s.Keys(), s.Periods()
+	results := make([]WeeklyCount, 0, len(keys)*len(periods))
+	for _, key := range keys {
+		for i, p := range periods {
+			start, end := periodDates(s.Bucketing(), p)
+			results = append(results, WeeklyCount{
+				Particulars: key,
+				Week:        p.Label,
+				PeriodStart: start,
+				PeriodEnd:   end,
+				Count:       s.Values(key)[i],
+			})
+		}
+	}
+	return results
+}
+
+// weeklyCapital lays out the capital build-up as one row per period
+func weeklyCapital(s *timeseries.Series[float64]) []WeeklyCapitalBuildUp {
+	values, periods := s.Ensure(capitalBuildUp), s.Periods()
+	results := make([]WeeklyCapitalBuildUp, 0, len(periods))
+	for i, p := range periods {
+		start, end := periodDates(s.Bucketing(), p)
+		results = append(results, WeeklyCapitalBuildUp{
+			Title:        capitalBuildUp,
+			Week:         p.Label,
+			PeriodStart:  start,
+			PeriodEnd:    end,
+			TotalCapital: values[i],
+		})
+	}
+	return results
+}
+
+// periodDates returns the first and last day of p, left empty for the
+// week-of-month periods so their rows keep the v1 shape
+func periodDates(b timeseries.Bucketing, p timeseries.Period) (string, string) {
+	if b.Granularity == timeseries.WeekOfMonth {
+		return "", ""
+	}
+	return p.Start.Format(dateLayout), p.End.Format(dateLayout)
+}
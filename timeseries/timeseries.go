@@ -0,0 +1,228 @@
+// Package timeseries splits a date range into calendar periods, such as ISO
+// weeks or quarters, and sums daily values into them with every period of the
+// range present, zero when nothing happened in it.
+package timeseries
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Granularity is the length of the periods a range is split into
+type Granularity string
+
+const (
+	Day     Granularity = "day"
+	Week    Granularity = "week"
+	Month   Granularity = "month"
+	Quarter Granularity = "quarter"
+	Year    Granularity = "year"
+	// WeekOfMonth splits every month into the days 1-7, 8-14, 15-21, 22-28
+	// and 29 to its end, labelled Week 1 to Week 5 as the v1 trend reports
+	// always were. It is the default and cannot be requested by name.
+	WeekOfMonth Granularity = "week_of_month"
+)
+
+// Granularities lists every granularity a request may name, finest first
+var Granularities = []Granularity{Day, Week, Month, Quarter, Year}
+
+// MaxPeriods caps how many periods a range may be split into
+const MaxPeriods = 1000
+
+// ParseGranularity returns the granularity named by s, ignoring case
+func ParseGranularity(s string) (Granularity, bool) {
+	g := Granularity(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Granularities {
+		if g == known {
+			return g, true
+		}
+	}
+	return "", false
+}
+
+// ParseWeekday returns the weekday named by s, such as monday, ignoring case
+func ParseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if s == strings.ToLower(d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// Bucketing assigns dates to the calendar periods of a granularity
+type Bucketing struct {
+	Granularity Granularity
+	// WeekStart is the first day of a week period; weeks starting on Monday
+	// are ISO 8601 weeks and are labelled as such
+	WeekStart time.Weekday
+}
+
+// Default buckets by week of the month, keeping the v1 labels until a request
+// names a granularity; WeekStart then applies to week periods
+var Default = Bucketing{Granularity: WeekOfMonth, WeekStart: time.Monday}
+
+// Period is one calendar period; Start and End are its first and last day,
+// which may fall outside the requested range for the periods at its edges
+type Period struct {
+	Start time.Time
+	End   time.Time
+	// Label names the period, such as 2024-05-03, 2024-W18, 2024-05, 2024-Q2 or 2024
+	Label string
+}
+
+// Start returns the first day of the period containing t
+func (b Bucketing) Start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch b.Granularity {
+	case Week:
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) - int(b.WeekStart) + 7) % 7))
+	case WeekOfMonth:
+		return time.Date(y, m, (d-1)/7*7+1, 0, 0, 0, 0, time.UTC)
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case Quarter:
+		return time.Date(y, (m-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	case Year:
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// next returns the first day of the period after the one starting at start
+func (b Bucketing) next(start time.Time) time.Time {
+	switch b.Granularity {
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case WeekOfMonth:
+		// The fifth week ends with its month, however short
+		if next := start.AddDate(0, 0, 7); next.Month() == start.Month() {
+			return next
+		}
+		return time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	case Quarter:
+		return start.AddDate(0, 3, 0)
+	case Year:
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Period returns the period containing t
+func (b Bucketing) Period(t time.Time) Period {
+	start := b.Start(t)
+	return Period{Start: start, End: b.next(start).AddDate(0, 0, -1), Label: b.label(start)}
+}
+
+// label names the period starting at start
+func (b Bucketing) label(start time.Time) string {
+	switch b.Granularity {
+	case Week:
+		if b.WeekStart == time.Monday {
+			year, week := start.ISOWeek()
+			return fmt.Sprintf("%04d-W%02d", year, week)
+		}
+		return "Week of " + start.Format(time.DateOnly)
+	case WeekOfMonth:
+		return fmt.Sprintf("Week %d", (start.Day()-1)/7+1)
+	case Month:
+		return start.Format("2006-01")
+	case Quarter:
+		return fmt.Sprintf("%04d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	case Year:
+		return start.Format("2006")
+	}
+	return start.Format(time.DateOnly)
+}
+
+// Count returns how many periods overlap [from, to], stopping once it
+// exceeds MaxPeriods
+func (b Bucketing) Count(from, to time.Time) int {
+	n := 0
+	for start, last := b.Start(from), b.Start(to); !start.After(last) && n <= MaxPeriods; start = b.next(start) {
+		n++
+	}
+	return n
+}
+
+// Periods returns every period overlapping [from, to] in order
+func (b Bucketing) Periods(from, to time.Time) []Period {
+	var periods []Period
+	for start, last := b.Start(from), b.Start(to); !start.After(last); start = b.next(start) {
+		periods = append(periods, b.Period(start))
+	}
+	return periods
+}
+
+// Number is a value that can be summed into a Series
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+// Series sums values per key and period over a date range
+type Series[V Number] struct {
+	periods []Period
+	// index maps the first day of each period onto its position
+	index  map[time.Time]int
+	b      Bucketing
+	values map[string][]V
+}
+
+// NewSeries returns an empty series over the periods of [from, to]
+func NewSeries[V Number](b Bucketing, from, to time.Time) *Series[V] {
+	s := &Series[V]{periods: b.Periods(from, to), index: map[time.Time]int{}, b: b, values: map[string][]V{}}
+	for i, p := range s.periods {
+		s.index[p.Start] = i
+	}
+	return s
+}
+
+// Add adds v to key's period containing day; days outside the range are ignored
+func (s *Series[V]) Add(key string, day time.Time, v V) {
+	i, ok := s.index[s.b.Start(day)]
+	if !ok {
+		return
+	}
+	s.Ensure(key)[i] += v
+}
+
+// Ensure includes key in the series, zero in every period until values are added
+func (s *Series[V]) Ensure(key string) []V {
+	values, ok := s.values[key]
+	if !ok {
+		values = make([]V, len(s.periods))
+		s.values[key] = values
+	}
+	return values
+}
+
+// Periods returns the periods of the series in order
+func (s *Series[V]) Periods() []Period {
+	return s.periods
+}
+
+// Bucketing returns the bucketing the series was built with
+func (s *Series[V]) Bucketing() Bucketing {
+	return s.b
+}
+
+// Keys returns the keys of the series in sorted order
+func (s *Series[V]) Keys() []string {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Values returns key's value per period, aligned with Periods
+func (s *Series[V]) Values(key string) []V {
+	return s.values[key]
+}
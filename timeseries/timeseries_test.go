@@ -0,0 +1,169 @@
+package timeseries
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestPeriods(t *testing.T) {
+	iso := Bucketing{Granularity: Week, WeekStart: time.Monday}
+	sunday := Bucketing{Granularity: Week, WeekStart: time.Sunday}
+
+	tests := []struct {
+		name     string
+		b        Bucketing
+		from, to string
+		// want holds the start, end and label of every period
+		want [][3]string
+	}{
+		{
+			name: "ISO week across the year boundary",
+			b:    iso, from: "2024-12-30", to: "2025-01-05",
+			want: [][3]string{{"2024-12-30", "2025-01-05", "2025-W01"}},
+		},
+		{
+			name: "ISO weeks from the last week of 2020",
+			b:    iso, from: "2020-12-31", to: "2021-01-04",
+			want: [][3]string{
+				{"2020-12-28", "2021-01-03", "2020-W53"},
+				{"2021-01-04", "2021-01-10", "2021-W01"},
+			},
+		},
+		{
+			name: "Sunday weeks",
+			b:    sunday, from: "2024-12-30", to: "2025-01-05",
+			want: [][3]string{
+				{"2024-12-29", "2025-01-04", "Week of 2024-12-29"},
+				{"2025-01-05", "2025-01-11", "Week of 2025-01-05"},
+			},
+		},
+		{
+			name: "ISO weeks spanning months are not merged",
+			b:    iso, from: "2024-04-29", to: "2024-05-12",
+			want: [][3]string{
+				{"2024-04-29", "2024-05-05", "2024-W18"},
+				{"2024-05-06", "2024-05-12", "2024-W19"},
+			},
+		},
+		{
+			name: "days",
+			b:    Bucketing{Granularity: Day}, from: "2024-02-28", to: "2024-03-01",
+			want: [][3]string{
+				{"2024-02-28", "2024-02-28", "2024-02-28"},
+				{"2024-02-29", "2024-02-29", "2024-02-29"},
+				{"2024-03-01", "2024-03-01", "2024-03-01"},
+			},
+		},
+		{
+			name: "months",
+			b:    Bucketing{Granularity: Month}, from: "2024-01-15", to: "2024-03-02",
+			want: [][3]string{
+				{"2024-01-01", "2024-01-31", "2024-01"},
+				{"2024-02-01", "2024-02-29", "2024-02"},
+				{"2024-03-01", "2024-03-31", "2024-03"},
+			},
+		},
+		{
+			name: "quarters across the year boundary",
+			b:    Bucketing{Granularity: Quarter}, from: "2024-11-15", to: "2025-02-01",
+			want: [][3]string{
+				{"2024-10-01", "2024-12-31", "2024-Q4"},
+				{"2025-01-01", "2025-03-31", "2025-Q1"},
+			},
+		},
+		{
+			name: "years",
+			b:    Bucketing{Granularity: Year}, from: "2023-12-31", to: "2024-01-01",
+			want: [][3]string{
+				{"2023-01-01", "2023-12-31", "2023"},
+				{"2024-01-01", "2024-12-31", "2024"},
+			},
+		},
+		{
+			name: "weeks of the month across months",
+			b:    Default, from: "2024-02-20", to: "2024-03-08",
+			want: [][3]string{
+				{"2024-02-15", "2024-02-21", "Week 3"},
+				{"2024-02-22", "2024-02-28", "Week 4"},
+				{"2024-02-29", "2024-02-29", "Week 5"},
+				{"2024-03-01", "2024-03-07", "Week 1"},
+				{"2024-03-08", "2024-03-14", "Week 2"},
+			},
+		},
+		{
+			name: "fifth week of a 31-day month",
+			b:    Default, from: "2024-01-31", to: "2024-01-31",
+			want: [][3]string{{"2024-01-29", "2024-01-31", "Week 5"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][3]string
+			for _, p := range tt.b.Periods(date(tt.from), date(tt.to)) {
+				got = append(got, [3]string{p.Start.Format(time.DateOnly), p.End.Format(time.DateOnly), p.Label})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Periods(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+			if n := tt.b.Count(date(tt.from), date(tt.to)); n != len(tt.want) {
+				t.Errorf("Count(%s, %s) = %d, want %d", tt.from, tt.to, n, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestCountStopsPastMaxPeriods(t *testing.T) {
+	b := Bucketing{Granularity: Day}
+	from := date("2000-01-01")
+
+	if n := b.Count(from, from.AddDate(0, 0, MaxPeriods-1)); n != MaxPeriods {
+		t.Errorf("Count of %d days = %d", MaxPeriods, n)
+	}
+	if n := b.Count(from, from.AddDate(50, 0, 0)); n != MaxPeriods+1 {
+		t.Errorf("Count of 50 years of days = %d, want it to stop at %d", n, MaxPeriods+1)
+	}
+}
+
+func TestSeriesZeroFills(t *testing.T) {
+	s := NewSeries[int](Bucketing{Granularity: Month}, date("2024-01-01"), date("2024-04-30"))
+	s.Add("Active", date("2024-01-10"), 1)
+	s.Add("Active", date("2024-01-20"), 1)
+	s.Add("Active", date("2024-04-01"), 1)
+	s.Add("Active", date("2023-12-31"), 1) // before the range
+	s.Add("Active", date("2024-05-01"), 1) // after the range
+	s.Ensure("Resigned")
+
+	if got, want := s.Keys(), []string{"Active", "Resigned"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if got, want := s.Values("Active"), []int{2, 0, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values(Active) = %v, want %v", got, want)
+	}
+	if got, want := s.Values("Resigned"), []int{0, 0, 0, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values(Resigned) = %v, want %v", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if g, ok := ParseGranularity(" Quarter "); !ok || g != Quarter {
+		t.Errorf("ParseGranularity(Quarter) = %q, %v", g, ok)
+	}
+	if _, ok := ParseGranularity(string(WeekOfMonth)); ok {
+		t.Error("ParseGranularity accepted the default week-of-month granularity by name")
+	}
+	if d, ok := ParseWeekday("SUNDAY"); !ok || d != time.Sunday {
+		t.Errorf("ParseWeekday(SUNDAY) = %v, %v", d, ok)
+	}
+	if _, ok := ParseWeekday("someday"); ok {
+		t.Error("ParseWeekday accepted someday")
+	}
+}
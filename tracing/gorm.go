@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey is the statement setting holding the statement's span
+const spanKey = "tracing:span"
+
+// GormPlugin records a client span for every query, row and raw statement,
+// as a child of the request span found in the statement's context
+type GormPlugin struct {
+	// Database is reported as db.namespace
+	Database string
+}
+
+// Name implements gorm.Plugin
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin, registering the span callbacks
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Query().Before("gorm:query").Register("tracing:before_query", p.before),
+		cb.Query().After("gorm:query").Register("tracing:after_query", p.after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", p.before),
+		cb.Row().After("gorm:row").Register("tracing:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", p.after),
+	)
+}
+
+func (p GormPlugin) before(db *gorm.DB) {
+	if db.Statement == nil || db.Statement.Context == nil {
+		return
+	}
+	ctx, span := tracer().Start(db.Statement.Context, "db",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBNamespace(p.Database)),
+	)
+	db.Statement.Context = ctx
+	db.InstanceSet(spanKey, span)
+}
+
+func (p GormPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	// The text keeps its placeholders, so no filter values reach the trace
+	query := db.Statement.SQL.String()
+	operation := operationName(query)
+	span.SetName(operation + " " + p.Database)
+	span.SetAttributes(
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(query),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
+
+// operationName returns the statement's leading keyword, such as SELECT or WITH
+func operationName(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,48 @@
+// Package versioning mounts the API under a version prefix and keeps the
+// original unversioned paths as deprecated aliases, so result shapes can
+// change in a later version without breaking v1 consumers.
+package versioning
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// V1 is the prefix of the first API version
+const V1 = "/api/v1"
+
+// Successor returns the v1 path of a legacy path: the same path in lower case
+// under V1, so /AO-dashboard/Total-loans becomes /api/v1/ao-dashboard/total-loans
+func Successor(path string) string {
+	return V1 + strings.ToLower(path)
+}
+
+// Deprecated is middleware for the legacy paths announcing their deprecation
+// (RFC 9745), their planned removal (RFC 8594) and their v1 successor in a
+// Link header. A zero date leaves its header out until one is configured. The
+// headers are set before the rest of the chain runs so error responses carry
+// them too.
+func Deprecated(deprecatedAt, sunset time.Time) fiber.Handler {
+	var deprecation, sunsetDate string
+	if !deprecatedAt.IsZero() {
+		deprecation = "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	}
+	if !sunset.IsZero() {
+		sunsetDate = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *fiber.Ctx) error {
+		if deprecation != "" {
+			c.Set("Deprecation", deprecation)
+		}
+		if sunsetDate != "" {
+			c.Set("Sunset", sunsetDate)
+		}
+		c.Append(fiber.HeaderLink, "<"+Successor(c.Path())+`>; rel="successor-version"`)
+		return c.Next()
+	}
+}